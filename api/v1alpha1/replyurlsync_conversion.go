@@ -0,0 +1,229 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+/*
+ConversionDataAnnotation holds the v1beta1 spec of a ReplyURLSync that has been read as
+v1alpha1 when the spec has fields v1alpha1 can't represent, so they aren't lost when the
+v1alpha1 object is written back.
+*/
+const ConversionDataAnnotation = "appregistrations.azure.hmcts.net/conversion-data"
+
+var _ conversion.Convertible = &ReplyURLSync{}
+
+// ConvertTo converts this ReplyURLSync to the Hub version (v1beta1)
+func (src *ReplyURLSync) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.ReplyURLSync)
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	delete(dst.Annotations, ConversionDataAnnotation)
+
+	// Restore the fields only found in v1beta1 before setting the fields v1alpha1 has
+	if data, found := src.Annotations[ConversionDataAnnotation]; found {
+		if err := json.Unmarshal([]byte(data), &dst.Spec); err != nil {
+			return err
+		}
+	}
+
+	src.Spec.convertTo(&dst.Spec)
+	src.Status.convertTo(&dst.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *ReplyURLSync) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.ReplyURLSync)
+
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	dst.Spec.convertFrom(&src.Spec)
+	dst.Status.convertFrom(&src.Status)
+
+	// Keep the v1beta1 spec if converting back wouldn't give a spec that syncs the same way
+	roundTrip := v1beta1.ReplyURLSyncSpec{}
+	dst.Spec.convertTo(&roundTrip)
+
+	if !equality.Semantic.DeepEqual(defaultedSpec(roundTrip), defaultedSpec(*src.Spec.DeepCopy())) {
+		data, err := json.Marshal(src.Spec)
+		if err != nil {
+			return err
+		}
+
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[ConversionDataAnnotation] = string(data)
+	}
+
+	return nil
+}
+
+func (src *ReplyURLSyncSpec) convertTo(dst *v1beta1.ReplyURLSyncSpec) {
+	dst.Source.IngressClassFilter = stringValue(src.IngressClassFilter)
+	dst.Target.ObjectID = stringValue(src.ObjectID)
+	dst.Credentials.TenantID = stringValue(src.TenantID)
+	dst.Credentials.ClientID = stringValue(src.ClientID)
+	dst.Filters.DomainFilter = stringValue(src.DomainFilter)
+	dst.Filters.ReplyURLFilter = stringValue(src.ReplyURLFilter)
+
+	// v1alpha1 always gave every host an https reply URL on the web platform
+	if dst.Target.URLTemplate == "" {
+		dst.Target.URLTemplate = v1beta1.HTTPSURLTemplate
+	}
+	if dst.Target.Platform == "" {
+		dst.Target.Platform = v1beta1.PlatformWeb
+	}
+
+	dst.Credentials.ClientSecret = v1beta1.ClientSecret{}
+	if src.ClientSecret != nil {
+		dst.Credentials.ClientSecret.EnvVarClientSecret = stringValue(src.ClientSecret.EnvVarClientSecret)
+
+		if keyVaultSecret := src.ClientSecret.KeyVaultClientSecret; keyVaultSecret != nil {
+			dst.Credentials.ClientSecret.KeyVaultClientSecret = &v1beta1.KeyVaultClientSecret{
+				KeyVaultName: keyVaultSecret.KeyVaultName,
+				SecretName:   keyVaultSecret.SecretName,
+			}
+		}
+	}
+}
+
+func (dst *ReplyURLSyncSpec) convertFrom(src *v1beta1.ReplyURLSyncSpec) {
+	*dst = ReplyURLSyncSpec{
+		TenantID:           stringPtr(src.Credentials.TenantID),
+		ClientID:           stringPtr(src.Credentials.ClientID),
+		ObjectID:           stringPtr(src.Target.ObjectID),
+		IngressClassFilter: optionalStringPtr(src.Source.IngressClassFilter),
+		DomainFilter:       optionalStringPtr(src.Filters.DomainFilter),
+		ReplyURLFilter:     optionalStringPtr(src.Filters.ReplyURLFilter),
+		ClientSecret: &ClientSecret{
+			EnvVarClientSecret: optionalStringPtr(src.Credentials.ClientSecret.EnvVarClientSecret),
+		},
+	}
+
+	if keyVaultSecret := src.Credentials.ClientSecret.KeyVaultClientSecret; keyVaultSecret != nil {
+		dst.ClientSecret.KeyVaultClientSecret = &KeyVaultClientSecret{
+			KeyVaultName: keyVaultSecret.KeyVaultName,
+			SecretName:   keyVaultSecret.SecretName,
+		}
+	}
+}
+
+func (src *ReplyURLSyncStatus) convertTo(dst *v1beta1.ReplyURLSyncStatus) {
+	*dst = v1beta1.ReplyURLSyncStatus{
+		ObservedGeneration: src.ObservedGeneration,
+		LastError:          src.LastError,
+		ManagedURLs:        src.ManagedURLs,
+		AddedURLs:          src.AddedURLs,
+		RemovedURLs:        src.RemovedURLs,
+		Conflicts:          src.Conflicts,
+		LastSyncTime:       src.LastSyncTime.DeepCopy(),
+	}
+
+	for _, condition := range src.Conditions {
+		dst.Conditions = append(dst.Conditions, *condition.DeepCopy())
+	}
+	if src.SyncedHosts != nil {
+		dst.SyncedHosts = append([]string{}, src.SyncedHosts...)
+	}
+	if src.InvalidURLs != nil {
+		dst.InvalidURLs = append([]string{}, src.InvalidURLs...)
+	}
+	if src.UnmanagedURLs != nil {
+		dst.UnmanagedURLs = append([]string{}, src.UnmanagedURLs...)
+	}
+
+	// An empty ledger is kept, so converting doesn't make the next sync take ownership of reply URLs again
+	if src.OwnedURLs != nil {
+		dst.OwnedURLs = map[v1beta1.Platform][]string{}
+		for platform, urls := range src.OwnedURLs {
+			dst.OwnedURLs[v1beta1.Platform(platform)] = append([]string{}, urls...)
+		}
+	}
+}
+
+func (dst *ReplyURLSyncStatus) convertFrom(src *v1beta1.ReplyURLSyncStatus) {
+	*dst = ReplyURLSyncStatus{
+		ObservedGeneration: src.ObservedGeneration,
+		LastError:          src.LastError,
+		ManagedURLs:        src.ManagedURLs,
+		AddedURLs:          src.AddedURLs,
+		RemovedURLs:        src.RemovedURLs,
+		Conflicts:          src.Conflicts,
+		LastSyncTime:       src.LastSyncTime.DeepCopy(),
+	}
+
+	for _, condition := range src.Conditions {
+		dst.Conditions = append(dst.Conditions, *condition.DeepCopy())
+	}
+	if src.SyncedHosts != nil {
+		dst.SyncedHosts = append([]string{}, src.SyncedHosts...)
+	}
+	if src.InvalidURLs != nil {
+		dst.InvalidURLs = append([]string{}, src.InvalidURLs...)
+	}
+	if src.UnmanagedURLs != nil {
+		dst.UnmanagedURLs = append([]string{}, src.UnmanagedURLs...)
+	}
+
+	if src.OwnedURLs != nil {
+		dst.OwnedURLs = map[string][]string{}
+		for platform, urls := range src.OwnedURLs {
+			dst.OwnedURLs[string(platform)] = append([]string{}, urls...)
+		}
+	}
+}
+
+/*
+defaultedSpec returns spec with its defaults set, so fields left to their defaults aren't seen
+as v1beta1 only fields. The default URL template gives every host an https reply URL unless
+the scheme is taken from the TLS config, like the URL template of v1alpha1.
+*/
+func defaultedSpec(spec v1beta1.ReplyURLSyncSpec) v1beta1.ReplyURLSyncSpec {
+	spec.Default()
+
+	if spec.Target.URLTemplate == v1beta1.DefaultURLTemplate && !spec.Target.SchemeFromTLS {
+		spec.Target.URLTemplate = v1beta1.HTTPSURLTemplate
+	}
+	return spec
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+// optionalStringPtr returns nil for empty strings so optional fields are left out
+func optionalStringPtr(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
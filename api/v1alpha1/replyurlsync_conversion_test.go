@@ -0,0 +1,222 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestReplyURLSync() *ReplyURLSync {
+	var (
+		tenantID           = "21ae17a1-694c-4005-8e0f-6a0e51c35a5f"
+		clientID           = "2816f198-4c26-48bb-8732-e4ca72926ba7"
+		objectID           = "850e80c0-e09e-489d-b12d-5e80cd1bca6a"
+		domainFilter       = ".*.sandbox.platform.hmcts.net"
+		replyURLFilter     = ".*.sandbox.platform.hmcts.net"
+		ingressClassFilter = "traefik"
+	)
+
+	return &ReplyURLSync{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-reply-url-sync",
+			Namespace: "default",
+		},
+		Spec: ReplyURLSyncSpec{
+			TenantID:           &tenantID,
+			ClientID:           &clientID,
+			ObjectID:           &objectID,
+			DomainFilter:       &domainFilter,
+			ReplyURLFilter:     &replyURLFilter,
+			IngressClassFilter: &ingressClassFilter,
+			ClientSecret: &ClientSecret{
+				KeyVaultClientSecret: &KeyVaultClientSecret{
+					KeyVaultName: "reply-urls-kv",
+					SecretName:   "reply-urls-operator-client-secret",
+				},
+			},
+		},
+		Status: ReplyURLSyncStatus{
+			ObservedGeneration: 2,
+			ManagedURLs:        1,
+			SyncedHosts:        []string{"test-app-1.sandbox.platform.hmcts.net"},
+			InvalidURLs:        []string{"http://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"},
+			Conflicts:          1,
+			OwnedURLs: map[string][]string{
+				"Web": {"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"},
+			},
+			UnmanagedURLs: []string{"https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback"},
+			Conditions: []metav1.Condition{
+				{
+					Type:   ConditionReady,
+					Status: metav1.ConditionTrue,
+					Reason: "Synced",
+				},
+			},
+		},
+	}
+}
+
+func TestConvertReplyURLSync(t *testing.T) {
+	original := newTestReplyURLSync()
+
+	hub := &v1beta1.ReplyURLSync{}
+	if err := original.ConvertTo(hub); err != nil {
+		t.Fatalf("Unable to convert to v1beta1: %v\nTest: %s\n", err, t.Name())
+	}
+
+	expectedSpec := v1beta1.ReplyURLSyncSpec{
+		Source: v1beta1.SourceSpec{
+			IngressClassFilter: "traefik",
+		},
+		Target: v1beta1.TargetSpec{
+			ObjectID:    "850e80c0-e09e-489d-b12d-5e80cd1bca6a",
+			URLTemplate: v1beta1.HTTPSURLTemplate,
+			Platform:    v1beta1.PlatformWeb,
+		},
+		Credentials: v1beta1.CredentialsSpec{
+			TenantID: "21ae17a1-694c-4005-8e0f-6a0e51c35a5f",
+			ClientID: "2816f198-4c26-48bb-8732-e4ca72926ba7",
+			ClientSecret: v1beta1.ClientSecret{
+				KeyVaultClientSecret: &v1beta1.KeyVaultClientSecret{
+					KeyVaultName: "reply-urls-kv",
+					SecretName:   "reply-urls-operator-client-secret",
+				},
+			},
+		},
+		Filters: v1beta1.FiltersSpec{
+			DomainFilter:   ".*.sandbox.platform.hmcts.net",
+			ReplyURLFilter: ".*.sandbox.platform.hmcts.net",
+		},
+	}
+
+	if !reflect.DeepEqual(hub.Spec, expectedSpec) {
+		t.Errorf("Result %+v not equal to the expected result %+v\nTest: %s\n", hub.Spec, expectedSpec, t.Name())
+	}
+
+	expectedOwnedURLs := map[v1beta1.Platform][]string{
+		v1beta1.PlatformWeb: {"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"},
+	}
+	if !reflect.DeepEqual(hub.Status.OwnedURLs, expectedOwnedURLs) || hub.Status.Conflicts != 1 ||
+		!reflect.DeepEqual(hub.Status.InvalidURLs, original.Status.InvalidURLs) ||
+		!reflect.DeepEqual(hub.Status.UnmanagedURLs, original.Status.UnmanagedURLs) {
+		t.Errorf("Result %+v not equal to the expected result %+v\nTest: %s\n", hub.Status, original.Status, t.Name())
+	}
+
+	roundTrip := &ReplyURLSync{}
+	if err := roundTrip.ConvertFrom(hub); err != nil {
+		t.Fatalf("Unable to convert from v1beta1: %v\nTest: %s\n", err, t.Name())
+	}
+
+	if _, found := roundTrip.Annotations[ConversionDataAnnotation]; found {
+		t.Errorf("Conversion data annotation set when nothing would be lost\nTest: %s\n", t.Name())
+	}
+
+	if !reflect.DeepEqual(roundTrip.Spec, original.Spec) || !reflect.DeepEqual(roundTrip.Status, original.Status) {
+		t.Errorf("Result %+v not equal to the expected result %+v\nTest: %s\n", roundTrip, original, t.Name())
+	}
+}
+
+func TestConvertReplyURLSyncKeepsV1beta1Fields(t *testing.T) {
+	hub := &v1beta1.ReplyURLSync{}
+	if err := newTestReplyURLSync().ConvertTo(hub); err != nil {
+		t.Fatalf("Unable to convert to v1beta1: %v\nTest: %s\n", err, t.Name())
+	}
+	hub.Spec.Target.URLTemplate = "https://{{ .Host }}/signin-oidc"
+
+	spoke := &ReplyURLSync{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("Unable to convert from v1beta1: %v\nTest: %s\n", err, t.Name())
+	}
+
+	if _, found := spoke.Annotations[ConversionDataAnnotation]; !found {
+		t.Fatalf("Conversion data annotation not set when the URL template would be lost\nTest: %s\n", t.Name())
+	}
+
+	roundTrip := &v1beta1.ReplyURLSync{}
+	if err := spoke.ConvertTo(roundTrip); err != nil {
+		t.Fatalf("Unable to convert to v1beta1: %v\nTest: %s\n", err, t.Name())
+	}
+
+	if _, found := roundTrip.Annotations[ConversionDataAnnotation]; found {
+		t.Errorf("Conversion data annotation kept on the v1beta1 object\nTest: %s\n", t.Name())
+	}
+
+	if !reflect.DeepEqual(roundTrip.Spec, hub.Spec) {
+		t.Errorf("Result %+v not equal to the expected result %+v\nTest: %s\n", roundTrip.Spec, hub.Spec, t.Name())
+	}
+}
+
+func TestConvertDefaultedReplyURLSync(t *testing.T) {
+	tests := []struct {
+		name               string
+		spec               func(spec *v1beta1.ReplyURLSyncSpec)
+		expectedAnnotation bool
+	}{
+		{
+			name:               "defaults",
+			spec:               func(spec *v1beta1.ReplyURLSyncSpec) {},
+			expectedAnnotation: false,
+		},
+		{
+			name: "default url template",
+			spec: func(spec *v1beta1.ReplyURLSyncSpec) {
+				spec.Target.URLTemplate = v1beta1.DefaultURLTemplate
+			},
+			expectedAnnotation: false,
+		},
+		{
+			name: "delete deletion policy",
+			spec: func(spec *v1beta1.ReplyURLSyncSpec) {
+				spec.DeletionPolicy = v1beta1.DeletionPolicyDelete
+			},
+			expectedAnnotation: true,
+		},
+		{
+			name: "scheme from tls",
+			spec: func(spec *v1beta1.ReplyURLSyncSpec) {
+				spec.Target.URLTemplate = v1beta1.DefaultURLTemplate
+				spec.Target.SchemeFromTLS = true
+			},
+			expectedAnnotation: true,
+		},
+	}
+
+	for _, test := range tests {
+		hub := &v1beta1.ReplyURLSync{}
+		if err := newTestReplyURLSync().ConvertTo(hub); err != nil {
+			t.Fatalf("Unable to convert to v1beta1: %v\nTest: %s\n", err, test.name)
+		}
+		test.spec(&hub.Spec)
+		hub.Default()
+
+		spoke := &ReplyURLSync{}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatalf("Unable to convert from v1beta1: %v\nTest: %s\n", err, test.name)
+		}
+
+		if _, found := spoke.Annotations[ConversionDataAnnotation]; found != test.expectedAnnotation {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", found, test.expectedAnnotation, test.name)
+		}
+	}
+}
+
+func TestConvertReplyURLSyncKeepsEmptyOwnedURLs(t *testing.T) {
+	hub := &v1beta1.ReplyURLSync{Status: v1beta1.ReplyURLSyncStatus{OwnedURLs: map[v1beta1.Platform][]string{}}}
+
+	spoke := &ReplyURLSync{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("Unable to convert from v1beta1: %v\nTest: %s\n", err, t.Name())
+	}
+
+	roundTrip := &v1beta1.ReplyURLSync{}
+	if err := spoke.ConvertTo(roundTrip); err != nil {
+		t.Fatalf("Unable to convert to v1beta1: %v\nTest: %s\n", err, t.Name())
+	}
+
+	// An empty ledger isn't the same as no ledger, which would be seeded by the next sync
+	if roundTrip.Status.OwnedURLs == nil || len(roundTrip.Status.OwnedURLs) != 0 {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", roundTrip.Status.OwnedURLs, hub.Status.OwnedURLs, t.Name())
+	}
+}
@@ -28,13 +28,14 @@ type ReplyURLSyncSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	TenantID           *string       `json:"tenantID"`
-	ClientID           *string       `json:"clientID"`
-	ObjectID           *string       `json:"objectID"`
-	ClientSecret       *ClientSecret `json:"clientSecret"`
-	DomainFilter       *string       `json:"domainFilter,omitempty"`
-	IngressClassFilter *string       `json:"ingressClassFilter,omitempty"`
-	ReplyURLFilter     *string       `json:"replyURLFilter,omitempty"`
+	TenantID     *string       `json:"tenantID"`
+	ClientID     *string       `json:"clientID"`
+	ObjectID     *string       `json:"objectID"`
+	ClientSecret *ClientSecret `json:"clientSecret"`
+	// +kubebuilder:default=".*"
+	DomainFilter       *string `json:"domainFilter,omitempty"`
+	IngressClassFilter *string `json:"ingressClassFilter,omitempty"`
+	ReplyURLFilter     *string `json:"replyURLFilter,omitempty"`
 }
 
 // ClientSecret defines the state of the client secret used to authenticate
@@ -49,15 +50,80 @@ type KeyVaultClientSecret struct {
 	SecretName   string `json:"secretName"`
 }
 
-// ReplyURLSyncStatus ReplyURLStatus defines the observed state of ReplyURLSync
+// Condition types reported in the status of a ReplyURLSync
+const (
+	// ConditionReady is true when the credentials were resolved and the last sync succeeded
+	ConditionReady = "Ready"
+	// ConditionCredentialsResolved is true when the client secret and ids needed to call Graph were found
+	ConditionCredentialsResolved = "CredentialsResolved"
+	// ConditionSynced is true when the app registration was last updated without error
+	ConditionSynced = "Synced"
+	// ConditionDegraded is true when the last attempt to sync failed
+	ConditionDegraded = "Degraded"
+)
+
+// ReplyURLSyncStatus defines the observed state of ReplyURLSync
 type ReplyURLSyncStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
-	SyncedHosts []string `json:"syncedHosts"`
+	// Conditions describe the current state of the sync
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the spec last processed by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is the last time the app registration was synced successfully
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// LastError is the message of the last error seen while syncing, cleared on success
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// ManagedURLs is the number of reply URLs generated from the hosts on the cluster
+	// +optional
+	ManagedURLs int32 `json:"managedURLs,omitempty"`
+
+	// AddedURLs is the number of reply URLs added to the app registration by the last sync
+	// +optional
+	AddedURLs int32 `json:"addedURLs,omitempty"`
+
+	// RemovedURLs is the number of reply URLs removed from the app registration by the last sync
+	// +optional
+	RemovedURLs int32 `json:"removedURLs,omitempty"`
+
+	// SyncedHosts are the hosts on the cluster that reply URLs are being managed for
+	// +optional
+	SyncedHosts []string `json:"syncedHosts,omitempty"`
+
+	// InvalidURLs are the reply URLs generated from hosts on the cluster that Azure wouldn't accept, so aren't synced
+	// +optional
+	InvalidURLs []string `json:"invalidURLs,omitempty"`
+
+	// Conflicts is the number of times the reply URLs of the app registration were changed by another writer during the last sync
+	// +optional
+	Conflicts int32 `json:"conflicts,omitempty"`
+
+	// OwnedURLs are the reply URLs the operator has added to the app registration by platform
+	// +optional
+	OwnedURLs map[string][]string `json:"ownedURLs"`
+
+	// UnmanagedURLs are the reply URLs on the app registration matching the reply URL filter that the operator didn't add
+	// +optional
+	UnmanagedURLs []string `json:"unmanagedURLs,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:deprecatedversion:warning="appregistrations.azure.hmcts.net/v1alpha1 ReplyURLSync is deprecated, use appregistrations.azure.hmcts.net/v1beta1"
+//+kubebuilder:printcolumn:name="Ingress Class",type="string",JSONPath=".spec.ingressClassFilter"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Managed",type="integer",JSONPath=".status.managedURLs"
+//+kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ReplyURLSync is the Schema for the replyurlsyncs API
 type ReplyURLSync struct {
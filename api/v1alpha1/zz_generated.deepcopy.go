@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -177,11 +178,48 @@ func (in *ReplyURLSyncSpec) DeepCopy() *ReplyURLSyncSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplyURLSyncStatus) DeepCopyInto(out *ReplyURLSyncStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
 	if in.SyncedHosts != nil {
 		in, out := &in.SyncedHosts, &out.SyncedHosts
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.InvalidURLs != nil {
+		in, out := &in.InvalidURLs, &out.InvalidURLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OwnedURLs != nil {
+		in, out := &in.OwnedURLs, &out.OwnedURLs
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.UnmanagedURLs != nil {
+		in, out := &in.UnmanagedURLs, &out.UnmanagedURLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplyURLSyncStatus.
@@ -0,0 +1,44 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+/*
+Host is a host exposed by a resource on the cluster, such as the host of an ingress rule.
+Its fields are the variables that can be used in a reply URL template, both when reply URLs
+are generated and when the validating webhook tries out a template before it is admitted.
+*/
+// +kubebuilder:object:generate=false
+type Host struct {
+	// Host is the hostname reply URLs are generated for
+	Host string
+	// Scheme is https when the host is served with TLS, resources without TLS config of their own are assumed to be
+	Scheme string
+	// Path is the path the host is exposed on without a trailing slash, only set for Ingresses and IngressRoutes
+	Path string
+	// Paths are all the paths an Ingress rule serves the host on, used instead of Path for path aware reply URLs
+	Paths []string
+	// Kind is the kind of the resource exposing the host e.g. Ingress
+	Kind string
+	// Namespace is the namespace of the resource
+	Namespace string
+	// Name is the name of the resource
+	Name string
+	// Labels are the labels of the resource
+	Labels map[string]string
+	// Annotations are the annotations of the resource
+	Annotations map[string]string
+}
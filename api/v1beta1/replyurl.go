@@ -0,0 +1,47 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+/*
+ValidReplyURL reports whether Azure would accept a reply URL. Reply URLs have to use https,
+apart from http reply URLs for localhost which are allowed when allowHTTP is set.
+*/
+func ValidReplyURL(replyURL string, allowHTTP bool) bool {
+	parsedURL, err := url.Parse(replyURL)
+	if err != nil || parsedURL.Hostname() == "" {
+		return false
+	}
+
+	switch parsedURL.Scheme {
+	case "https":
+		return true
+	case "http":
+		if !allowHTTP {
+			return false
+		}
+		hostname := parsedURL.Hostname()
+		ip := net.ParseIP(hostname)
+		return strings.EqualFold(hostname, "localhost") || (ip != nil && ip.IsLoopback())
+	}
+	return false
+}
@@ -0,0 +1,505 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// DefaultDomainFilter is the domain filter used when one isn't set, it matches every host
+const DefaultDomainFilter = ".*"
+
+// DefaultURLTemplate is the reply URL template used when one isn't set, the callback of an oauth2-proxy
+const DefaultURLTemplate = "{{ .Scheme }}://{{ .Host }}/oauth-proxy/callback"
+
+// HTTPSURLTemplate is the oauth2-proxy callback with https for every host, the reply URL of v1alpha1 ReplyURLSyncs
+const HTTPSURLTemplate = "https://{{ .Host }}/oauth-proxy/callback"
+
+// Annotations that can be set on an ingress to change how its hosts are synced
+const (
+	// IgnoreAnnotation set to "true" stops reply URLs being generated for the hosts of the ingress
+	IgnoreAnnotation = "appregistrations.azure.hmcts.net/ignore"
+	// CallbackPathsAnnotation is a comma separated list of paths used instead of the URL templates of the sync
+	CallbackPathsAnnotation = "appregistrations.azure.hmcts.net/callback-paths"
+	// ReplyURLSyncAnnotation is the name, or namespace/name, of the only ReplyURLSync that should sync the ingress
+	ReplyURLSyncAnnotation = "appregistrations.azure.hmcts.net/reply-url-sync"
+	// PlatformAnnotation is the platform the reply URLs of the ingress are added to instead of the platform of the sync
+	PlatformAnnotation = "appregistrations.azure.hmcts.net/platform"
+)
+
+// Platform is the collection of redirect URIs on an app registration that reply URLs are synced to
+// +kubebuilder:validation:Enum=Web;SPA;PublicClient
+type Platform string
+
+const (
+	// PlatformWeb syncs the reply URLs to web.redirectUris, used by server side apps
+	PlatformWeb Platform = "Web"
+	// PlatformSPA syncs the reply URLs to spa.redirectUris, used by single page apps with MSAL
+	PlatformSPA Platform = "SPA"
+	// PlatformPublicClient syncs the reply URLs to publicClient.redirectUris, used by mobile and desktop apps
+	PlatformPublicClient Platform = "PublicClient"
+)
+
+// Platforms are all of the platforms reply URLs can be synced to
+var Platforms = []Platform{PlatformWeb, PlatformSPA, PlatformPublicClient}
+
+// DeletionPolicy is what happens to the reply URLs managed by a ReplyURLSync when it is deleted
+// +kubebuilder:validation:Enum=Retain;Delete
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain leaves the reply URLs on the app registration
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyDelete removes the reply URLs managed by the sync from the app registration
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+)
+
+// UnmanagedURLPolicy is what happens to reply URLs on the app registration matching the reply URL filter that the operator didn't add
+// +kubebuilder:validation:Enum=Ignore;Report;Remove
+type UnmanagedURLPolicy string
+
+const (
+	// UnmanagedURLPolicyIgnore leaves unmanaged reply URLs on the app registration
+	UnmanagedURLPolicyIgnore UnmanagedURLPolicy = "Ignore"
+	// UnmanagedURLPolicyReport leaves unmanaged reply URLs on the app registration and lists them on the status of the sync
+	UnmanagedURLPolicyReport UnmanagedURLPolicy = "Report"
+	// UnmanagedURLPolicyRemove removes unmanaged reply URLs from the app registration
+	UnmanagedURLPolicyRemove UnmanagedURLPolicy = "Remove"
+)
+
+// ReplyURLSyncFinalizer is kept on ReplyURLSyncs with the Delete deletion policy until their reply URLs have been removed
+const ReplyURLSyncFinalizer = "appregistrations.azure.hmcts.net/finalizer"
+
+// ReplyURLSyncSpec defines the desired state of ReplyURLSync
+type ReplyURLSyncSpec struct {
+	// Source selects the resources on the cluster that reply URLs are generated from
+	Source SourceSpec `json:"source"`
+
+	// Target is the app registration that has its reply URLs kept in sync
+	Target TargetSpec `json:"target"`
+
+	// Credentials are used to authenticate with Microsoft Graph when updating the target
+	Credentials CredentialsSpec `json:"credentials"`
+
+	// Filters limit which hosts and reply URLs are managed by the operator
+	// +optional
+	Filters FiltersSpec `json:"filters,omitempty"`
+
+	/*
+		DeletionPolicy is what happens to the reply URLs managed by the sync when it is deleted.
+		Retain leaves them on the app registration, Delete removes them, apart from the ones also
+		managed by another ReplyURLSync for the same app registration, before the sync is released.
+	*/
+	// +kubebuilder:default=Retain
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	/*
+		UnmanagedURLPolicy is what happens to reply URLs on the app registration that match the
+		reply URL filter but weren't added by the operator, such as ones added by hand. Ignore
+		leaves them, Report leaves them and lists them in .status.unmanagedURLs, Remove removes them.
+		Reply URLs the operator added are removed when their hosts go whatever the policy.
+	*/
+	// +kubebuilder:default=Ignore
+	// +optional
+	UnmanagedURLPolicy UnmanagedURLPolicy `json:"unmanagedURLPolicy,omitempty"`
+}
+
+// SourceSpec defines the resources on the cluster that reply URLs are generated from
+type SourceSpec struct {
+	/*
+		IngressClassFilter is the ingress class of the ingresses to sync e.g. traefik. Ingresses
+		without a class are in the IngressClass marked as the default class of the cluster.
+	*/
+	// +optional
+	IngressClassFilter string `json:"ingressClassFilter,omitempty"`
+
+	// IngressClasses are more ingress classes to sync, so one sync can keep the hosts of e.g. public and private classes
+	// +listType=set
+	// +optional
+	IngressClasses []string `json:"ingressClasses,omitempty"`
+
+	// IngressController syncs the ingresses of every IngressClass with this controller e.g. traefik.io/ingress-controller
+	// +optional
+	IngressController string `json:"ingressController,omitempty"`
+
+	// HTTPRoute syncs the hostnames of Gateway API HTTPRoutes attached to the selected Gateways
+	// +optional
+	HTTPRoute *HTTPRouteSource `json:"httpRoute,omitempty"`
+
+	// IngressRoute syncs the hosts in the match rules of Traefik IngressRoutes
+	// +optional
+	IngressRoute *IngressRouteSource `json:"ingressRoute,omitempty"`
+
+	// VirtualService syncs the hosts of Istio VirtualServices bound to the selected Gateways
+	// +optional
+	VirtualService *VirtualServiceSource `json:"virtualService,omitempty"`
+
+	// ExternalDNS syncs the hostnames external-dns publishes for Services and DNSEndpoints
+	// +optional
+	ExternalDNS *ExternalDNSSource `json:"externalDNS,omitempty"`
+
+	// Knative syncs the hosts of the URLs of Knative Services and DomainMappings
+	// +optional
+	Knative *KnativeSource `json:"knative,omitempty"`
+
+	// Resources syncs the hosts of any other kind of resource, read from them with JSONPath expressions
+	// +optional
+	Resources []ResourceSource `json:"resources,omitempty"`
+
+	// NamespaceSelector selects the namespaces of the ingresses to sync, every namespace is selected when it isn't set
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// LabelSelector selects the ingresses to sync by their labels, every ingress is selected when it isn't set
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// IngressClassNames returns the ingress class filter and the other ingress classes of the source
+func (source SourceSpec) IngressClassNames() (classNames []string) {
+	if source.IngressClassFilter != "" {
+		classNames = append(classNames, source.IngressClassFilter)
+	}
+
+	for _, className := range source.IngressClasses {
+		if className != source.IngressClassFilter {
+			classNames = append(classNames, className)
+		}
+	}
+	return classNames
+}
+
+// HTTPRouteSource selects Gateway API HTTPRoutes by the Gateways they are attached to
+type HTTPRouteSource struct {
+	// GatewayClassName selects routes attached to a Gateway of this class
+	// +optional
+	GatewayClassName string `json:"gatewayClassName,omitempty"`
+
+	// Gateways selects routes attached to one of these Gateways, given as name or namespace/name
+	// +optional
+	Gateways []string `json:"gateways,omitempty"`
+}
+
+// IngressRouteSource selects Traefik IngressRoutes, every IngressRoute is selected when no fields are set
+type IngressRouteSource struct {
+	// IngressClass selects routes with this kubernetes.io/ingress.class annotation, which Traefik uses
+	// to pick the routes of an instance
+	// +optional
+	IngressClass string `json:"ingressClass,omitempty"`
+
+	// EntryPoints selects routes using at least one of these entry points e.g. websecure
+	// +optional
+	EntryPoints []string `json:"entryPoints,omitempty"`
+}
+
+// VirtualServiceSource selects Istio VirtualServices by the Gateways they are bound to
+type VirtualServiceSource struct {
+	/*
+		Gateways selects VirtualServices bound to one of these Gateways, given as namespace/name or as
+		name for Gateways in the namespace of the VirtualService, the same way as in the VirtualService
+	*/
+	Gateways []string `json:"gateways"`
+}
+
+// ExternalDNSSource selects the resources external-dns publishes hostnames in DNS for
+type ExternalDNSSource struct {
+	// Services syncs the hostnames in the external-dns.alpha.kubernetes.io/hostname annotation of LoadBalancer Services
+	// +optional
+	Services bool `json:"services,omitempty"`
+
+	// DNSEndpoints syncs the DNS names of the A, AAAA and CNAME records of external-dns DNSEndpoints
+	// +optional
+	DNSEndpoints bool `json:"dnsEndpoints,omitempty"`
+}
+
+// KnativeSource selects the Knative resources that have the hosts of their URLs synced
+type KnativeSource struct {
+	// Services syncs the hosts in the status.url of serving.knative.dev Services that aren't cluster local
+	// +optional
+	Services bool `json:"services,omitempty"`
+
+	// DomainMappings syncs the hosts in the status.url of serving.knative.dev DomainMappings
+	// +optional
+	DomainMappings bool `json:"domainMappings,omitempty"`
+}
+
+/*
+ResourceSource reads hosts from the resources of a kind the operator doesn't have a source for,
+e.g. Contour HTTPProxies or OpenShift Routes. JSONPath expressions are written like they are for
+kubectl, the surrounding braces can be left out.
+*/
+type ResourceSource struct {
+	// APIVersion is the group and version of the resources e.g. projectcontour.io/v1
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the resources e.g. HTTPProxy
+	Kind string `json:"kind"`
+
+	// HostsPath is a JSONPath expression giving the hosts of a resource e.g. {.spec.virtualhost.fqdn}
+	HostsPath string `json:"hostsPath"`
+
+	// ClassPath is a JSONPath expression giving the class of a resource e.g. {.spec.ingressClassName}
+	// +optional
+	ClassPath string `json:"classPath,omitempty"`
+
+	// Class selects the resources with this class at ClassPath, every resource is selected when it isn't set
+	// +optional
+	Class string `json:"class,omitempty"`
+}
+
+// GroupVersionKind returns the group, version and kind of the resources
+func (resource ResourceSource) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(resource.APIVersion, resource.Kind)
+}
+
+// ParseJSONPath parses a JSONPath expression, wrapping it in braces if they have been left out
+func ParseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(strings.TrimSpace(path), "{") {
+		path = "{" + path + "}"
+	}
+
+	parser := jsonpath.New("").AllowMissingKeys(true)
+	if err := parser.Parse(path); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// TargetSpec defines the app registration that has its reply URLs kept in sync
+type TargetSpec struct {
+	// ObjectID is the object id of the app registration
+	ObjectID string `json:"objectID"`
+
+	/*
+		URLTemplate is a Go template rendered for every matched host to give its reply URL.
+		The variables .Host, .Path, .Namespace, .Name, .Labels and .Annotations are set from the
+		ingress rule and the ingress it belongs to, .Path is the first path of the rule without
+		a trailing slash e.g. "https://{{ .Host }}{{ .Path }}/signin-oidc". .Scheme is https
+		unless SchemeFromTLS is set.
+	*/
+	// +kubebuilder:default="{{ .Scheme }}://{{ .Host }}/oauth-proxy/callback"
+	// +optional
+	URLTemplate string `json:"urlTemplate,omitempty"`
+
+	// URLTemplates are more templates rendered for every matched host, for apps that need
+	// more than one reply URL e.g. a login callback and a silent renew URL
+	// +listType=set
+	// +optional
+	URLTemplates []string `json:"urlTemplates,omitempty"`
+
+	// Platform is the collection of redirect URIs the reply URLs are synced to, it can be
+	// changed for the hosts of an ingress with the platform annotation
+	// +kubebuilder:default=Web
+	// +optional
+	Platform Platform `json:"platform,omitempty"`
+
+	/*
+		PathAware generates reply URLs for every path of an ingress rule rather than only for its
+		host, with the path put in front of the path of the reply URL e.g.
+		https://host/case-api/oauth-proxy/callback for an app served on /case-api of a shared host.
+		Exact paths are skipped as nothing under them is served.
+	*/
+	// +optional
+	PathAware bool `json:"pathAware,omitempty"`
+
+	/*
+		AllowHTTP allows http reply URLs for localhost, e.g. on a kind cluster with SchemeFromTLS. Azure only accepts
+		http reply URLs for localhost, other reply URLs that aren't https are never synced and
+		are reported in the status instead.
+	*/
+	// +optional
+	AllowHTTP bool `json:"allowHTTP,omitempty"`
+
+	/*
+		SchemeFromTLS works out the scheme of the reply URLs of an ingress from its TLS config,
+		hosts covered by it get https and the others http. Without it every host gets https, which
+		suits clusters where TLS is terminated in front of the ingress controller, e.g. by Front Door.
+		Hosts of other resources always get https.
+	*/
+	// +optional
+	SchemeFromTLS bool `json:"schemeFromTLS,omitempty"`
+
+	/*
+		StaticURLs are reply URLs that are always kept on the platform of the app registration, as
+		well as the reply URLs of the hosts on the cluster, e.g. http://localhost:3000/callback for
+		developers or the callback of an external service. They are removed by the operator when
+		they are taken out of StaticURLs or the sync is deleted with the Delete deletion policy, and
+		http is allowed for localhost without AllowHTTP.
+	*/
+	// +listType=set
+	// +optional
+	StaticURLs []string `json:"staticURLs,omitempty"`
+}
+
+// Templates returns the URL template and the additional URL templates of the target
+func (target TargetSpec) Templates() []string {
+	urlTemplate := target.URLTemplate
+	if urlTemplate == "" {
+		urlTemplate = DefaultURLTemplate
+	}
+
+	return append([]string{urlTemplate}, target.URLTemplates...)
+}
+
+// CredentialsSpec defines the app registration used to authenticate with Microsoft Graph
+type CredentialsSpec struct {
+	// TenantID is the id of the tenant the app registrations belong to
+	TenantID string `json:"tenantID"`
+
+	// ClientID is the client id of the app registration used to authenticate
+	ClientID string `json:"clientID"`
+
+	// ClientSecret is where to get the client secret of the app registration used to authenticate
+	ClientSecret ClientSecret `json:"clientSecret"`
+}
+
+// ClientSecret defines where the client secret used to authenticate is read from, exactly one must be set
+type ClientSecret struct {
+	// KeyVaultClientSecret reads the client secret from an Azure Key Vault
+	// +optional
+	KeyVaultClientSecret *KeyVaultClientSecret `json:"keyVaultClientSecret,omitempty"`
+
+	// EnvVarClientSecret is the name of an environment variable of the operator holding the client secret
+	// +optional
+	EnvVarClientSecret string `json:"envVarClientSecret,omitempty"`
+}
+
+// KeyVaultClientSecret defines the state of a client secret retrieved from an Azure Key Vault
+type KeyVaultClientSecret struct {
+	KeyVaultName string `json:"keyVaultName"`
+	SecretName   string `json:"secretName"`
+}
+
+// FiltersSpec defines which hosts and reply URLs are managed by the operator
+type FiltersSpec struct {
+	// DomainFilter is a regex matching the hosts to generate reply URLs for
+	// +kubebuilder:default=".*"
+	// +optional
+	DomainFilter string `json:"domainFilter,omitempty"`
+
+	// ReplyURLFilter is a regex matching the reply URLs on the app registration that the unmanaged
+	// URL policy applies to, defaults to the reply URLs of the hosts matched by DomainFilter
+	// +optional
+	ReplyURLFilter string `json:"replyURLFilter,omitempty"`
+}
+
+// Condition types reported in the status of a ReplyURLSync
+const (
+	// ConditionReady is true when the credentials were resolved and the last sync succeeded
+	ConditionReady = "Ready"
+	// ConditionCredentialsResolved is true when the client secret and ids needed to call Graph were found
+	ConditionCredentialsResolved = "CredentialsResolved"
+	// ConditionSynced is true when the app registration was last updated without error
+	ConditionSynced = "Synced"
+	// ConditionDegraded is true when the last attempt to sync failed
+	ConditionDegraded = "Degraded"
+)
+
+// ReplyURLSyncStatus defines the observed state of ReplyURLSync
+type ReplyURLSyncStatus struct {
+	// Conditions describe the current state of the sync
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the spec last processed by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is the last time the app registration was synced successfully
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// LastError is the message of the last error seen while syncing, cleared on success
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// ManagedURLs is the number of reply URLs generated from the hosts on the cluster, static URLs aren't counted
+	// +optional
+	ManagedURLs int32 `json:"managedURLs,omitempty"`
+
+	// AddedURLs is the number of reply URLs added to the app registration by the last sync
+	// +optional
+	AddedURLs int32 `json:"addedURLs,omitempty"`
+
+	// RemovedURLs is the number of reply URLs removed from the app registration by the last sync
+	// +optional
+	RemovedURLs int32 `json:"removedURLs,omitempty"`
+
+	// SyncedHosts are the hosts on the cluster that reply URLs are being managed for
+	// +optional
+	SyncedHosts []string `json:"syncedHosts,omitempty"`
+
+	// InvalidURLs are the reply URLs generated from hosts on the cluster that Azure wouldn't accept, so aren't synced
+	// +optional
+	InvalidURLs []string `json:"invalidURLs,omitempty"`
+
+	// Conflicts is the number of times the reply URLs of the app registration were changed by another writer during the last sync
+	// +optional
+	Conflicts int32 `json:"conflicts,omitempty"`
+
+	/*
+		OwnedURLs are the reply URLs the operator has added to the app registration by platform, only
+		these are removed when their hosts go and only from the platform they were added to. It is
+		unset until the first successful sync, which takes ownership of the managed reply URLs already
+		on the app registration, and is kept when empty so that only happens once.
+	*/
+	// +optional
+	OwnedURLs map[Platform][]string `json:"ownedURLs"`
+
+	// UnmanagedURLs are the reply URLs on the app registration matching the reply URL filter that the operator didn't add, only listed with the Report unmanaged URL policy
+	// +optional
+	UnmanagedURLs []string `json:"unmanagedURLs,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ingress Class",type="string",JSONPath=".spec.source.ingressClassFilter"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+//+kubebuilder:printcolumn:name="Managed",type="integer",JSONPath=".status.managedURLs"
+//+kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ReplyURLSync is the Schema for the replyurlsyncs API
+type ReplyURLSync struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReplyURLSyncSpec   `json:"spec,omitempty"`
+	Status ReplyURLSyncStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ReplyURLSyncList contains a list of ReplyURLSync
+type ReplyURLSyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReplyURLSync `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReplyURLSync{}, &ReplyURLSyncList{})
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var (
+	replyurlsynclog = logf.Log.WithName("replyurlsync-resource")
+	uuidRegex       = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+func (r *ReplyURLSync) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-appregistrations-azure-hmcts-net-v1beta1-replyurlsync,mutating=true,failurePolicy=fail,sideEffects=None,groups=appregistrations.azure.hmcts.net,resources=replyurlsyncs,verbs=create;update,versions=v1beta1,name=mreplyurlsync.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &ReplyURLSync{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *ReplyURLSync) Default() {
+	replyurlsynclog.Info("default", "name", r.Name)
+
+	r.Spec.Default()
+}
+
+/*
+Default sets the filters, URL template, platform and deletion policy that haven't been set so
+the behaviour of the sync is explicit. The domain filter defaults to matching every host and the reply URL
+filter defaults to matching the reply URLs generated for the hosts matched by the domain filter.
+*/
+func (spec *ReplyURLSyncSpec) Default() {
+	if spec.Target.URLTemplate == "" {
+		spec.Target.URLTemplate = DefaultURLTemplate
+	}
+
+	if spec.Target.Platform == "" {
+		spec.Target.Platform = PlatformWeb
+	}
+
+	if spec.DeletionPolicy == "" {
+		spec.DeletionPolicy = DeletionPolicyRetain
+	}
+
+	if spec.UnmanagedURLPolicy == "" {
+		spec.UnmanagedURLPolicy = UnmanagedURLPolicyIgnore
+	}
+
+	if spec.Filters.DomainFilter == "" {
+		spec.Filters.DomainFilter = DefaultDomainFilter
+	}
+
+	if spec.Filters.ReplyURLFilter == "" {
+		spec.Filters.ReplyURLFilter = ReplyURLFilterFromDomainFilter(spec.Filters.DomainFilter)
+	}
+}
+
+/*
+ReplyURLFilterFromDomainFilter returns a regex that matches the reply URLs generated for
+the hosts matched by domainFilter. Filters are matched anywhere in the string so an
+unanchored domain filter already matches the reply URLs, anchors are moved to the
+start and end of the host in the URL. Each branch of a domain filter that is an
+alternation, e.g. ^a\.example\.com$|^b\.example\.com$, has its anchors moved separately.
+*/
+func ReplyURLFilterFromDomainFilter(domainFilter string) string {
+	branches := splitAlternation(domainFilter)
+	if len(branches) == 1 {
+		return replyURLBranchFilter(domainFilter)
+	}
+
+	for i, branch := range branches {
+		branches[i] = "(?:" + replyURLBranchFilter(branch) + ")"
+	}
+	return strings.Join(branches, "|")
+}
+
+// replyURLBranchFilter moves the anchors of a domain filter without alternations to the start and end of the host
+func replyURLBranchFilter(domainFilter string) string {
+	replyURLFilter := domainFilter
+
+	if strings.HasPrefix(replyURLFilter, "^") {
+		replyURLFilter = "^https?://" + strings.TrimPrefix(replyURLFilter, "^")
+	}
+
+	if strings.HasSuffix(replyURLFilter, "$") && !strings.HasSuffix(replyURLFilter, `\$`) {
+		replyURLFilter = strings.TrimSuffix(replyURLFilter, "$") + "(/|$)"
+	}
+
+	return replyURLFilter
+}
+
+// splitAlternation splits a regex at the | that aren't escaped or inside a group or character class
+func splitAlternation(regex string) (branches []string) {
+	var (
+		depth   int
+		inClass bool
+		start   int
+	)
+
+	for i := 0; i < len(regex); i++ {
+		switch c := regex[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			// A ] straight after the opening bracket, or its negation, is part of the class
+			if strings.HasPrefix(regex[i+1:], "^]") {
+				i += 2
+			} else if strings.HasPrefix(regex[i+1:], "]") {
+				i++
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '|' && depth == 0:
+			branches = append(branches, regex[start:i])
+			start = i + 1
+		}
+	}
+	return append(branches, regex[start:])
+}
+
+//+kubebuilder:webhook:path=/validate-appregistrations-azure-hmcts-net-v1beta1-replyurlsync,mutating=false,failurePolicy=fail,sideEffects=None,groups=appregistrations.azure.hmcts.net,resources=replyurlsyncs,verbs=create;update,versions=v1beta1,name=vreplyurlsync.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ReplyURLSync{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ReplyURLSync) ValidateCreate() error {
+	replyurlsynclog.Info("validate create", "name", r.Name)
+
+	return r.validateReplyURLSync()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ReplyURLSync) ValidateUpdate(old runtime.Object) error {
+	replyurlsynclog.Info("validate update", "name", r.Name)
+
+	return r.validateReplyURLSync()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ReplyURLSync) ValidateDelete() error {
+	return nil
+}
+
+func (r *ReplyURLSync) validateReplyURLSync() error {
+	allErrs := r.Spec.validate(field.NewPath("spec"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("ReplyURLSync").GroupKind(), r.Name, allErrs)
+}
+
+func (spec *ReplyURLSyncSpec) validate(specPath *field.Path) (allErrs field.ErrorList) {
+	sourcePath := specPath.Child("source")
+	credentialsPath := specPath.Child("credentials")
+	filtersPath := specPath.Child("filters")
+
+	allErrs = append(allErrs, spec.Source.validate(sourcePath)...)
+
+	selectorOpts := metav1validation.LabelSelectorValidationOptions{}
+	allErrs = append(allErrs, metav1validation.ValidateLabelSelector(spec.Source.NamespaceSelector, selectorOpts, sourcePath.Child("namespaceSelector"))...)
+	allErrs = append(allErrs, metav1validation.ValidateLabelSelector(spec.Source.LabelSelector, selectorOpts, sourcePath.Child("labelSelector"))...)
+
+	allErrs = append(allErrs, validateUUID(spec.Target.ObjectID, specPath.Child("target", "objectID"))...)
+	allErrs = append(allErrs, validateURLTemplate(spec.Target.URLTemplate, specPath.Child("target", "urlTemplate"))...)
+	for i, urlTemplate := range spec.Target.URLTemplates {
+		allErrs = append(allErrs, validateURLTemplate(urlTemplate, specPath.Child("target", "urlTemplates").Index(i))...)
+	}
+	for i, staticURL := range spec.Target.StaticURLs {
+		allErrs = append(allErrs, validateStaticURL(staticURL, specPath.Child("target", "staticURLs").Index(i))...)
+	}
+	allErrs = append(allErrs, validateUUID(spec.Credentials.TenantID, credentialsPath.Child("tenantID"))...)
+	allErrs = append(allErrs, validateUUID(spec.Credentials.ClientID, credentialsPath.Child("clientID"))...)
+	allErrs = append(allErrs, spec.Credentials.ClientSecret.validate(credentialsPath.Child("clientSecret"))...)
+	allErrs = append(allErrs, validateRegex(spec.Filters.DomainFilter, filtersPath.Child("domainFilter"))...)
+	allErrs = append(allErrs, validateRegex(spec.Filters.ReplyURLFilter, filtersPath.Child("replyURLFilter"))...)
+
+	return allErrs
+}
+
+// validate checks that at least one source of hosts has been set and that each of them selects something
+func (source *SourceSpec) validate(sourcePath *field.Path) (allErrs field.ErrorList) {
+	if source.IngressClassFilter == "" && len(source.IngressClasses) == 0 && source.IngressController == "" && source.HTTPRoute == nil &&
+		source.IngressRoute == nil && source.VirtualService == nil && source.ExternalDNS == nil && source.Knative == nil && len(source.Resources) == 0 {
+		allErrs = append(allErrs, field.Required(sourcePath, "one of ingressClassFilter, ingressClasses, ingressController, httpRoute, ingressRoute, virtualService, externalDNS, knative or resources must be set"))
+	}
+
+	for i, className := range source.IngressClasses {
+		if className == "" {
+			allErrs = append(allErrs, field.Required(sourcePath.Child("ingressClasses").Index(i), ""))
+		}
+	}
+
+	if httpRoute := source.HTTPRoute; httpRoute != nil && httpRoute.GatewayClassName == "" && len(httpRoute.Gateways) == 0 {
+		allErrs = append(allErrs, field.Required(sourcePath.Child("httpRoute"), "one of gatewayClassName or gateways must be set"))
+	}
+
+	if virtualService := source.VirtualService; virtualService != nil && len(virtualService.Gateways) == 0 {
+		allErrs = append(allErrs, field.Required(sourcePath.Child("virtualService", "gateways"), "at least one gateway must be set"))
+	}
+
+	if externalDNS := source.ExternalDNS; externalDNS != nil && !externalDNS.Services && !externalDNS.DNSEndpoints {
+		allErrs = append(allErrs, field.Required(sourcePath.Child("externalDNS"), "one of services or dnsEndpoints must be set"))
+	}
+
+	if knative := source.Knative; knative != nil && !knative.Services && !knative.DomainMappings {
+		allErrs = append(allErrs, field.Required(sourcePath.Child("knative"), "one of services or domainMappings must be set"))
+	}
+
+	for i, resource := range source.Resources {
+		allErrs = append(allErrs, resource.validate(sourcePath.Child("resources").Index(i))...)
+	}
+
+	return allErrs
+}
+
+// validate checks that the kind of the resources is set and that the JSONPath expressions parse
+func (resource *ResourceSource) validate(resourcePath *field.Path) (allErrs field.ErrorList) {
+	if resource.APIVersion == "" {
+		allErrs = append(allErrs, field.Required(resourcePath.Child("apiVersion"), ""))
+	} else if _, err := schema.ParseGroupVersion(resource.APIVersion); err != nil {
+		allErrs = append(allErrs, field.Invalid(resourcePath.Child("apiVersion"), resource.APIVersion, err.Error()))
+	}
+
+	if resource.Kind == "" {
+		allErrs = append(allErrs, field.Required(resourcePath.Child("kind"), ""))
+	}
+
+	if resource.HostsPath == "" {
+		allErrs = append(allErrs, field.Required(resourcePath.Child("hostsPath"), ""))
+	} else if _, err := ParseJSONPath(resource.HostsPath); err != nil {
+		allErrs = append(allErrs, field.Invalid(resourcePath.Child("hostsPath"), resource.HostsPath, err.Error()))
+	}
+
+	if resource.ClassPath == "" {
+		if resource.Class != "" {
+			allErrs = append(allErrs, field.Required(resourcePath.Child("classPath"), "classPath must be set to select resources by class"))
+		}
+	} else if _, err := ParseJSONPath(resource.ClassPath); err != nil {
+		allErrs = append(allErrs, field.Invalid(resourcePath.Child("classPath"), resource.ClassPath, err.Error()))
+	}
+
+	return allErrs
+}
+
+// validate checks that exactly one source has been set for the client secret
+func (clientSecret *ClientSecret) validate(clientSecretPath *field.Path) (allErrs field.ErrorList) {
+	keyVaultSecret := clientSecret.KeyVaultClientSecret
+	envVarSecret := clientSecret.EnvVarClientSecret
+
+	switch {
+	case keyVaultSecret == nil && envVarSecret == "":
+		allErrs = append(allErrs, field.Required(clientSecretPath, "one of keyVaultClientSecret or envVarClientSecret must be set"))
+	case keyVaultSecret != nil && envVarSecret != "":
+		allErrs = append(allErrs, field.Forbidden(clientSecretPath, "only one of keyVaultClientSecret or envVarClientSecret can be set"))
+	case keyVaultSecret != nil:
+		keyVaultPath := clientSecretPath.Child("keyVaultClientSecret")
+		if keyVaultSecret.KeyVaultName == "" {
+			allErrs = append(allErrs, field.Required(keyVaultPath.Child("keyVaultName"), ""))
+		}
+		if keyVaultSecret.SecretName == "" {
+			allErrs = append(allErrs, field.Required(keyVaultPath.Child("secretName"), ""))
+		}
+	}
+
+	return allErrs
+}
+
+func validateUUID(value string, fieldPath *field.Path) field.ErrorList {
+	if value == "" {
+		return field.ErrorList{field.Required(fieldPath, "")}
+	}
+	if !uuidRegex.MatchString(value) {
+		return field.ErrorList{field.Invalid(fieldPath, value, "must be a UUID")}
+	}
+	return nil
+}
+
+/*
+validateStaticURL checks that a static reply URL is an absolute URL Azure would accept, the
+same as at sync time http is only allowed for localhost
+*/
+func validateStaticURL(value string, fieldPath *field.Path) field.ErrorList {
+	parsedURL, err := url.Parse(value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fieldPath, value, err.Error())}
+	}
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return field.ErrorList{field.Invalid(fieldPath, value, "must be an absolute URL")}
+	}
+	if !ValidReplyURL(value, true) {
+		return field.ErrorList{field.Invalid(fieldPath, value, "must use https, http is only allowed for localhost")}
+	}
+	return nil
+}
+
+func validateRegex(value string, fieldPath *field.Path) field.ErrorList {
+	if _, err := regexp.Compile(value); err != nil {
+		return field.ErrorList{field.Invalid(fieldPath, value, err.Error())}
+	}
+	return nil
+}
+
+/*
+validateURLTemplate parses a reply URL template and renders it for a sample host, so variables
+that don't exist, e.g. .Hots, are rejected rather than failing every sync. Templates are rendered
+with the options used at sync time, where labels and annotations a resource doesn't have are empty.
+*/
+func validateURLTemplate(value string, fieldPath *field.Path) field.ErrorList {
+	urlTemplate, err := template.New("urlTemplate").Option("missingkey=zero").Parse(value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fieldPath, value, err.Error())}
+	}
+
+	sampleHost := Host{
+		Host:        "app.example.com",
+		Scheme:      "https",
+		Paths:       []string{""},
+		Kind:        "Ingress",
+		Namespace:   "default",
+		Name:        "app",
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	}
+	if err := urlTemplate.Execute(io.Discard, sampleHost); err != nil {
+		return field.ErrorList{field.Invalid(fieldPath, value, err.Error())}
+	}
+	return nil
+}
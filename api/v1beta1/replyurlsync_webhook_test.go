@@ -0,0 +1,388 @@
+package v1beta1
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestReplyURLSync() *ReplyURLSync {
+	return &ReplyURLSync{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-reply-url-sync",
+			Namespace: "default",
+		},
+		Spec: ReplyURLSyncSpec{
+			Source: SourceSpec{
+				IngressClassFilter: "traefik",
+			},
+			Target: TargetSpec{
+				ObjectID: "850e80c0-e09e-489d-b12d-5e80cd1bca6a",
+			},
+			Credentials: CredentialsSpec{
+				TenantID: "21ae17a1-694c-4005-8e0f-6a0e51c35a5f",
+				ClientID: "2816f198-4c26-48bb-8732-e4ca72926ba7",
+				ClientSecret: ClientSecret{
+					EnvVarClientSecret: "AZURE_CLIENT_SECRET",
+				},
+			},
+			Filters: FiltersSpec{
+				DomainFilter: ".*.sandbox.platform.hmcts.net",
+			},
+		},
+	}
+}
+
+func TestValidateReplyURLSync(t *testing.T) {
+	invalidRegex := ".*[sandbox"
+
+	tests := []struct {
+		name          string
+		mutate        func(sync *ReplyURLSync)
+		expectedField string
+	}{
+		{
+			name:   "valid",
+			mutate: func(sync *ReplyURLSync) {},
+		},
+		{
+			name:          "invalid domain filter",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Filters.DomainFilter = invalidRegex },
+			expectedField: "spec.filters.domainFilter",
+		},
+		{
+			name:          "invalid reply url filter",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Filters.ReplyURLFilter = invalidRegex },
+			expectedField: "spec.filters.replyURLFilter",
+		},
+		{
+			name:          "tenant id not a uuid",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Credentials.TenantID = "not-a-uuid" },
+			expectedField: "spec.credentials.tenantID",
+		},
+		{
+			name:          "missing object id",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Target.ObjectID = "" },
+			expectedField: "spec.target.objectID",
+		},
+		{
+			name:          "invalid url template",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Target.URLTemplate = "https://{{ .Host }/signin-oidc" },
+			expectedField: "spec.target.urlTemplate",
+		},
+		{
+			name:          "url template with an unknown variable",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Target.URLTemplate = "https://{{ .Hots }}/signin-oidc" },
+			expectedField: "spec.target.urlTemplate",
+		},
+		{
+			name: "url template with labels and annotations",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Target.URLTemplate = "https://{{ .Host }}/{{ .Labels.app }}/{{ index .Annotations \"example.com/path\" }}"
+			},
+		},
+		{
+			name: "invalid additional url template",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Target.URLTemplates = []string{"https://{{ .Host }}/silent-renew", "https://{{ .Host }/logout"}
+			},
+			expectedField: "spec.target.urlTemplates[1]",
+		},
+		{
+			name: "static urls",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Target.StaticURLs = []string{"http://localhost:3000/oauth-proxy/callback", "https://app.example.com/callback"}
+			},
+		},
+		{
+			name:          "relative static url",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Target.StaticURLs = []string{"/oauth-proxy/callback"} },
+			expectedField: "spec.target.staticURLs[0]",
+		},
+		{
+			name: "http static url",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Target.StaticURLs = []string{"http://127.0.0.1:3000/callback", "http://app.example.com/callback"}
+			},
+			expectedField: "spec.target.staticURLs[1]",
+		},
+		{
+			name:          "static url with another scheme",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Target.StaticURLs = []string{"ftp://app.example.com/callback"} },
+			expectedField: "spec.target.staticURLs[0]",
+		},
+		{
+			name: "invalid namespace selector",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.NamespaceSelector = &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "team", Operator: metav1.LabelSelectorOpIn},
+					},
+				}
+			},
+			expectedField: "spec.source.namespaceSelector.matchExpressions[0].values",
+		},
+		{
+			name: "invalid label selector",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.LabelSelector = &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app.kubernetes.io/name": "not a valid label value"},
+				}
+			},
+			expectedField: "spec.source.labelSelector.matchLabels",
+		},
+		{
+			name:          "no source",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Source.IngressClassFilter = "" },
+			expectedField: "spec.source",
+		},
+		{
+			name: "http route source only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.HTTPRoute = &HTTPRouteSource{Gateways: []string{"admin/public-gateway"}}
+			},
+		},
+		{
+			name: "ingress classes only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.IngressClasses = []string{"traefik", "traefik-private"}
+			},
+		},
+		{
+			name:          "empty ingress class",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Source.IngressClasses = []string{"traefik-private", ""} },
+			expectedField: "spec.source.ingressClasses[1]",
+		},
+		{
+			name: "ingress controller only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.IngressController = "traefik.io/ingress-controller"
+			},
+		},
+		{
+			name: "ingress route source only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.IngressRoute = &IngressRouteSource{}
+			},
+		},
+		{
+			name: "virtual service source only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.VirtualService = &VirtualServiceSource{Gateways: []string{"istio-system/public-gateway"}}
+			},
+		},
+		{
+			name:          "virtual service source without gateways",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Source.VirtualService = &VirtualServiceSource{} },
+			expectedField: "spec.source.virtualService.gateways",
+		},
+		{
+			name: "external-dns source only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.ExternalDNS = &ExternalDNSSource{Services: true}
+			},
+		},
+		{
+			name:          "external-dns source without services or dns endpoints",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Source.ExternalDNS = &ExternalDNSSource{} },
+			expectedField: "spec.source.externalDNS",
+		},
+		{
+			name: "knative source only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.Knative = &KnativeSource{Services: true, DomainMappings: true}
+			},
+		},
+		{
+			name:          "knative source without services or domain mappings",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Source.Knative = &KnativeSource{} },
+			expectedField: "spec.source.knative",
+		},
+		{
+			name: "resource source only",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.IngressClassFilter = ""
+				sync.Spec.Source.Resources = []ResourceSource{{
+					APIVersion: "projectcontour.io/v1",
+					Kind:       "HTTPProxy",
+					HostsPath:  ".spec.virtualhost.fqdn",
+					ClassPath:  "{.spec.ingressClassName}",
+					Class:      "contour",
+				}}
+			},
+		},
+		{
+			name: "resource source with an invalid hosts path",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.Resources = []ResourceSource{{APIVersion: "route.openshift.io/v1", Kind: "Route", HostsPath: "{.spec.host"}}
+			},
+			expectedField: "spec.source.resources[0].hostsPath",
+		},
+		{
+			name: "resource source with a class but no class path",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Source.Resources = []ResourceSource{{APIVersion: "route.openshift.io/v1", Kind: "Route", HostsPath: "{.spec.host}", Class: "public"}}
+			},
+			expectedField: "spec.source.resources[0].classPath",
+		},
+		{
+			name:          "http route source without gateways",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Source.HTTPRoute = &HTTPRouteSource{} },
+			expectedField: "spec.source.httpRoute",
+		},
+		{
+			name:          "no client secret source",
+			mutate:        func(sync *ReplyURLSync) { sync.Spec.Credentials.ClientSecret = ClientSecret{} },
+			expectedField: "spec.credentials.clientSecret",
+		},
+		{
+			name: "both client secret sources",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Credentials.ClientSecret.KeyVaultClientSecret = &KeyVaultClientSecret{
+					KeyVaultName: "reply-urls-kv",
+					SecretName:   "reply-urls-operator-client-secret",
+				}
+			},
+			expectedField: "spec.credentials.clientSecret",
+		},
+		{
+			name: "key vault secret without a secret name",
+			mutate: func(sync *ReplyURLSync) {
+				sync.Spec.Credentials.ClientSecret = ClientSecret{
+					KeyVaultClientSecret: &KeyVaultClientSecret{KeyVaultName: "reply-urls-kv"},
+				}
+			},
+			expectedField: "spec.credentials.clientSecret.keyVaultClientSecret.secretName",
+		},
+	}
+
+	for _, test := range tests {
+		sync := newTestReplyURLSync()
+		test.mutate(sync)
+
+		err := sync.ValidateCreate()
+
+		if test.expectedField == "" {
+			if err != nil {
+				t.Errorf("Expected no error, got %v\nTest: %s\n", err, test.name)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), test.expectedField) {
+			t.Errorf("Expected an error for %s, got %v\nTest: %s\n", test.expectedField, err, test.name)
+		}
+	}
+}
+
+func TestDefaultReplyURLSync(t *testing.T) {
+	tests := []struct {
+		name                   string
+		filters                FiltersSpec
+		expectedDomainFilter   string
+		expectedReplyURLFilter string
+		matchedURLs            []string
+		unmatchedURLs          []string
+	}{
+		{
+			name:                   "no filters",
+			expectedDomainFilter:   ".*",
+			expectedReplyURLFilter: ".*",
+		},
+		{
+			name: "unanchored domain filter",
+			filters: FiltersSpec{
+				DomainFilter: ".*.sandbox.platform.hmcts.net",
+			},
+			expectedDomainFilter:   ".*.sandbox.platform.hmcts.net",
+			expectedReplyURLFilter: ".*.sandbox.platform.hmcts.net",
+		},
+		{
+			name: "anchored domain filter",
+			filters: FiltersSpec{
+				DomainFilter: `^.*\.sandbox\.platform\.hmcts\.net$`,
+			},
+			expectedDomainFilter:   `^.*\.sandbox\.platform\.hmcts\.net$`,
+			expectedReplyURLFilter: `^https?://.*\.sandbox\.platform\.hmcts\.net(/|$)`,
+		},
+		{
+			name: "anchored alternation",
+			filters: FiltersSpec{
+				DomainFilter: `^a\.example\.com$|^b\.example\.com$`,
+			},
+			expectedDomainFilter:   `^a\.example\.com$|^b\.example\.com$`,
+			expectedReplyURLFilter: `(?:^https?://a\.example\.com(/|$))|(?:^https?://b\.example\.com(/|$))`,
+			matchedURLs:            []string{"https://a.example.com/oauth-proxy/callback", "https://b.example.com/oauth-proxy/callback"},
+			unmatchedURLs:          []string{"https://c.example.com/oauth-proxy/callback", "https://a.example.com.evil.net/callback"},
+		},
+		{
+			name: "alternation inside a group",
+			filters: FiltersSpec{
+				DomainFilter: `^(a|b)\.example\.com$`,
+			},
+			expectedDomainFilter:   `^(a|b)\.example\.com$`,
+			expectedReplyURLFilter: `^https?://(a|b)\.example\.com(/|$)`,
+			matchedURLs:            []string{"https://b.example.com/oauth-proxy/callback"},
+		},
+		{
+			name: "reply url filter already set",
+			filters: FiltersSpec{
+				DomainFilter:   ".*.sandbox.platform.hmcts.net",
+				ReplyURLFilter: ".*.platform.hmcts.net",
+			},
+			expectedDomainFilter:   ".*.sandbox.platform.hmcts.net",
+			expectedReplyURLFilter: ".*.platform.hmcts.net",
+		},
+	}
+
+	for _, test := range tests {
+		sync := newTestReplyURLSync()
+		sync.Spec.Filters = test.filters
+
+		sync.Default()
+
+		if sync.Spec.Filters.DomainFilter != test.expectedDomainFilter {
+			t.Errorf("Domain filter %s not equal to the expected %s\nTest: %s\n",
+				sync.Spec.Filters.DomainFilter, test.expectedDomainFilter, test.name)
+		}
+		if sync.Spec.Filters.ReplyURLFilter != test.expectedReplyURLFilter {
+			t.Errorf("Reply URL filter %s not equal to the expected %s\nTest: %s\n",
+				sync.Spec.Filters.ReplyURLFilter, test.expectedReplyURLFilter, test.name)
+		}
+		for _, url := range test.matchedURLs {
+			if matched, _ := regexp.MatchString(sync.Spec.Filters.ReplyURLFilter, url); !matched {
+				t.Errorf("Reply URL filter %s doesn't match %s\nTest: %s\n", sync.Spec.Filters.ReplyURLFilter, url, test.name)
+			}
+		}
+		for _, url := range test.unmatchedURLs {
+			if matched, _ := regexp.MatchString(sync.Spec.Filters.ReplyURLFilter, url); matched {
+				t.Errorf("Reply URL filter %s matches %s\nTest: %s\n", sync.Spec.Filters.ReplyURLFilter, url, test.name)
+			}
+		}
+		if sync.Spec.Target.Platform != PlatformWeb {
+			t.Errorf("Platform %s not equal to the expected %s\nTest: %s\n",
+				sync.Spec.Target.Platform, PlatformWeb, test.name)
+		}
+		if sync.Spec.Target.URLTemplate != DefaultURLTemplate {
+			t.Errorf("URL template %s not equal to the expected %s\nTest: %s\n",
+				sync.Spec.Target.URLTemplate, DefaultURLTemplate, test.name)
+		}
+		if sync.Spec.DeletionPolicy != DeletionPolicyRetain {
+			t.Errorf("Deletion policy %s not equal to the expected %s\nTest: %s\n",
+				sync.Spec.DeletionPolicy, DeletionPolicyRetain, test.name)
+		}
+		if sync.Spec.UnmanagedURLPolicy != UnmanagedURLPolicyIgnore {
+			t.Errorf("Unmanaged URL policy %s not equal to the expected %s\nTest: %s\n",
+				sync.Spec.UnmanagedURLPolicy, UnmanagedURLPolicyIgnore, test.name)
+		}
+	}
+}
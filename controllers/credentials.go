@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	azureGraph "github.com/hmcts/reply-urls-operator/controllers/pkg/azure"
+	"github.com/hmcts/reply-urls-operator/controllers/pkg/secrets"
+)
+
+// newAppRegistrations connects to the app registrations that can be updated with a set of credentials
+var newAppRegistrations = azureGraph.NewAppRegistrations
+
+// resolveCredentials returns the credentials used to authenticate with Graph for a
+// ReplyURLSync, getting the client secret from an environment variable or a Key Vault.
+func resolveCredentials(syncer v1beta1.ReplyURLSync) (creds azureGraph.ClientSecretCredentials, err error) {
+	var (
+		clientSecret *string
+
+		credsSpec = syncer.Spec.Credentials
+		resource  = "ReplyURLSync/" + syncer.Name
+	)
+
+	switch {
+	case credsSpec.ClientID == "":
+		return creds, azureGraph.FieldNotFoundError{Field: ".spec.credentials.clientID", Resource: resource}
+	case credsSpec.TenantID == "":
+		return creds, azureGraph.FieldNotFoundError{Field: ".spec.credentials.tenantID", Resource: resource}
+	case syncer.Spec.Target.ObjectID == "":
+		return creds, azureGraph.FieldNotFoundError{Field: ".spec.target.objectID", Resource: resource}
+	}
+
+	if envVarName := credsSpec.ClientSecret.EnvVarClientSecret; envVarName != "" {
+		if clientSecret, err = secrets.GetSecretFromEnv(envVarName); err != nil {
+			return creds, err
+		}
+	} else if keyVaultSecret := credsSpec.ClientSecret.KeyVaultClientSecret; keyVaultSecret != nil &&
+		keyVaultSecret.SecretName != "" && keyVaultSecret.KeyVaultName != "" {
+
+		// Get Secret from key vault
+		clientSecret, err = secrets.GetSecretFromVault(
+			keyVaultSecret.SecretName,
+			keyVaultSecret.KeyVaultName,
+		)
+		if err != nil {
+			return creds, err
+		}
+		if clientSecret == nil {
+			return creds, fmt.Errorf("secret %s in key vault %s is empty",
+				keyVaultSecret.SecretName, keyVaultSecret.KeyVaultName)
+		}
+	} else {
+		return creds, azureGraph.FieldNotFoundError{Field: ".spec.credentials.clientSecret", Resource: resource}
+	}
+
+	creds.ClientID = credsSpec.ClientID
+	creds.TenantID = credsSpec.TenantID
+	creds.ClientSecret = *clientSecret
+
+	return creds, nil
+}
+
+// isConfigError reports whether err was caused by the ReplyURLSync config, in which
+// case retrying won't help until the config is changed.
+func isConfigError(err error) bool {
+	var (
+		fieldNotFound  azureGraph.FieldNotFoundError
+		envVarNotFound secrets.EnvVarNotFoundError
+	)
+
+	return errors.As(err, &fieldNotFound) || errors.As(err, &envVarNotFound)
+}
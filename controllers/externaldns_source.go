@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// externalDNSHostnameAnnotation is the annotation external-dns publishes the hostnames of a Service from
+const externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+//+kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch
+
+var (
+	// ServiceSource reads hosts from the external-dns hostname annotation of LoadBalancer Services, it is watched once a sync uses it
+	ServiceSource ObjectSource = serviceSource{}
+	// DNSEndpointSource reads hosts from the records of external-dns DNSEndpoints
+	DNSEndpointSource ObjectSource = dnsEndpointSource{}
+
+	// dnsEndpointRecordTypes are the types of record that publish a host, other records like TXT don't
+	dnsEndpointRecordTypes = []string{"A", "AAAA", "CNAME"}
+)
+
+type serviceSource struct{}
+
+func (serviceSource) groupKind() schema.GroupKind {
+	return schema.GroupKind{Kind: "Service"}
+}
+
+func (serviceSource) usedBy(syncer v1beta1.ReplyURLSync) bool {
+	externalDNS := syncer.Spec.Source.ExternalDNS
+	return externalDNS != nil && externalDNS.Services
+}
+
+// objectHosts returns the hostnames external-dns publishes for a LoadBalancer Service
+func (source serviceSource) objectHosts(_ context.Context, _ client.Client, service *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	if !source.usedBy(syncer) {
+		return nil, nil
+	}
+
+	if serviceType, _, _ := unstructured.NestedString(service.Object, "spec", "type"); serviceType != "LoadBalancer" {
+		return nil, nil
+	}
+
+	for _, hostname := range strings.Split(service.GetAnnotations()[externalDNSHostnameAnnotation], ",") {
+		if hostname := dnsHostname(hostname); hostname != "" {
+			hosts = append(hosts, objectHost(service, hostname))
+		}
+	}
+	return hosts, nil
+}
+
+type dnsEndpointSource struct{}
+
+func (dnsEndpointSource) groupKind() schema.GroupKind {
+	return schema.GroupKind{Group: "externaldns.k8s.io", Kind: "DNSEndpoint"}
+}
+
+func (dnsEndpointSource) usedBy(syncer v1beta1.ReplyURLSync) bool {
+	externalDNS := syncer.Spec.Source.ExternalDNS
+	return externalDNS != nil && externalDNS.DNSEndpoints
+}
+
+// objectHosts returns the DNS names of the records of a DNSEndpoint that publish a host
+func (source dnsEndpointSource) objectHosts(_ context.Context, _ client.Client, dnsEndpoint *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	if !source.usedBy(syncer) {
+		return nil, nil
+	}
+
+	endpoints, _, err := unstructured.NestedSlice(dnsEndpoint.Object, "spec", "endpoints")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, endpointObj := range endpoints {
+		endpoint, ok := endpointObj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if recordType, _, _ := unstructured.NestedString(endpoint, "recordType"); !swag.ContainsStrings(dnsEndpointRecordTypes, recordType) {
+			continue
+		}
+
+		dnsName, _, _ := unstructured.NestedString(endpoint, "dnsName")
+		if hostname := dnsHostname(dnsName); hostname != "" {
+			hosts = append(hosts, objectHost(dnsEndpoint, hostname))
+		}
+	}
+	return hosts, nil
+}
+
+// dnsHostname returns a DNS name as a host without its trailing dot, wildcard names give no host
+func dnsHostname(dnsName string) string {
+	hostname := strings.TrimSuffix(strings.TrimSpace(dnsName), ".")
+	if strings.HasPrefix(hostname, "*") {
+		return ""
+	}
+	return hostname
+}
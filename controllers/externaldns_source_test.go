@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExternalDNSSourceObjectHosts(t *testing.T) {
+	service := newTestObject("v1", "Service", "test-namespace", "test-app", map[string]interface{}{
+		"type": "LoadBalancer",
+	})
+	service.SetAnnotations(map[string]string{
+		externalDNSHostnameAnnotation: "test-app-1.sandbox.platform.hmcts.net., test-app-2.sandbox.platform.hmcts.net,*.sandbox.platform.hmcts.net",
+	})
+
+	clusterIPService := service.DeepCopy()
+	_ = unstructured.SetNestedField(clusterIPService.Object, "ClusterIP", "spec", "type")
+
+	dnsEndpoint := newTestObject("externaldns.k8s.io/v1alpha1", "DNSEndpoint", "test-namespace", "test-app", map[string]interface{}{
+		"endpoints": []interface{}{
+			map[string]interface{}{"dnsName": "test-app-3.sandbox.platform.hmcts.net", "recordType": "A"},
+			map[string]interface{}{"dnsName": "test-app-4.sandbox.platform.hmcts.net", "recordType": "CNAME"},
+			map[string]interface{}{"dnsName": "test-app-5.sandbox.platform.hmcts.net", "recordType": "TXT"},
+		},
+	})
+
+	tests := []struct {
+		name          string
+		source        ObjectSource
+		obj           *unstructured.Unstructured
+		externalDNS   *v1beta1.ExternalDNSSource
+		expectedHosts []string
+	}{
+		{
+			name:   "no external-dns source",
+			source: ServiceSource,
+			obj:    service,
+		},
+		{
+			name:          "load balancer service",
+			source:        ServiceSource,
+			obj:           service,
+			externalDNS:   &v1beta1.ExternalDNSSource{Services: true},
+			expectedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:        "cluster ip service",
+			source:      ServiceSource,
+			obj:         clusterIPService,
+			externalDNS: &v1beta1.ExternalDNSSource{Services: true},
+		},
+		{
+			name:        "services not synced",
+			source:      ServiceSource,
+			obj:         service,
+			externalDNS: &v1beta1.ExternalDNSSource{DNSEndpoints: true},
+		},
+		{
+			name:          "dns endpoint",
+			source:        DNSEndpointSource,
+			obj:           dnsEndpoint,
+			externalDNS:   &v1beta1.ExternalDNSSource{DNSEndpoints: true},
+			expectedHosts: []string{"test-app-3.sandbox.platform.hmcts.net", "test-app-4.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:        "dns endpoints not synced",
+			source:      DNSEndpointSource,
+			obj:         dnsEndpoint,
+			externalDNS: &v1beta1.ExternalDNSSource{Services: true},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source.ExternalDNS = test.externalDNS
+
+		hosts, err := test.source.objectHosts(context.TODO(), fake.NewClientBuilder().Build(), test.obj, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostnames []string
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Host)
+		}
+
+		if !reflect.DeepEqual(hostnames, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", hostnames, test.expectedHosts, test.name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch
+
+// HTTPRouteSource reads hosts from the hostnames of Gateway API HTTPRoutes
+var HTTPRouteSource ObjectSource = httpRouteSource{}
+
+type httpRouteSource struct{}
+
+func (httpRouteSource) groupKind() schema.GroupKind {
+	return schema.GroupKind{Group: gatewayAPIGroup, Kind: "HTTPRoute"}
+}
+
+// dependencies are the Gateways routes are attached to, which decide whether a route is selected by its gateway class
+func (httpRouteSource) dependencies() []schema.GroupKind {
+	return []schema.GroupKind{{Group: gatewayAPIGroup, Kind: "Gateway"}}
+}
+
+func (httpRouteSource) usedBy(syncer v1beta1.ReplyURLSync) bool {
+	return syncer.Spec.Source.HTTPRoute != nil
+}
+
+// objectHosts returns the hostnames of a route attached to a Gateway selected by the sync
+func (httpRouteSource) objectHosts(ctx context.Context, c client.Client, route *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	routeSource := syncer.Spec.Source.HTTPRoute
+	if routeSource == nil {
+		return nil, nil
+	}
+
+	if attached, err := attachedToGateway(ctx, c, route, *routeSource); err != nil || !attached {
+		return nil, err
+	}
+
+	hostnames, _, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hostname := range hostnames {
+		// Reply URLs can't be generated for wildcard hostnames
+		if strings.HasPrefix(hostname, "*") {
+			continue
+		}
+		hosts = append(hosts, objectHost(route, hostname))
+	}
+	return hosts, nil
+}
+
+// attachedToGateway reports whether one of the parents of a route is a Gateway selected by routeSource
+func attachedToGateway(ctx context.Context, c client.Client, route *unstructured.Unstructured, routeSource v1beta1.HTTPRouteSource) (bool, error) {
+	parentRefs, _, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	if err != nil {
+		return false, err
+	}
+
+	for _, parentRef := range parentRefs {
+		gateway, isGateway := gatewayRef(parentRef, route.GetNamespace())
+		if !isGateway {
+			continue
+		}
+
+		for _, gatewayName := range routeSource.Gateways {
+			if namespace, name, isNamespaced := strings.Cut(gatewayName, "/"); isNamespaced {
+				if gateway.Namespace == namespace && gateway.Name == name {
+					return true, nil
+				}
+			} else if gateway.Name == gatewayName {
+				return true, nil
+			}
+		}
+
+		if routeSource.GatewayClassName == "" {
+			continue
+		}
+
+		gatewayObj := &unstructured.Unstructured{}
+		gatewayObj.SetGroupVersionKind(route.GroupVersionKind().GroupVersion().WithKind("Gateway"))
+		if err := c.Get(ctx, gateway, gatewayObj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+
+		if className, _, _ := unstructured.NestedString(gatewayObj.Object, "spec", "gatewayClassName"); className == routeSource.GatewayClassName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// gatewayRef returns the name of the Gateway a parentRef refers to, parentRefs to other kinds are ignored
+func gatewayRef(parentRef interface{}, routeNamespace string) (gateway types.NamespacedName, isGateway bool) {
+	ref, ok := parentRef.(map[string]interface{})
+	if !ok {
+		return gateway, false
+	}
+
+	group, found, _ := unstructured.NestedString(ref, "group")
+	if found && group != gatewayAPIGroup {
+		return gateway, false
+	}
+	if kind, found, _ := unstructured.NestedString(ref, "kind"); found && kind != "Gateway" {
+		return gateway, false
+	}
+
+	gateway.Name, _, _ = unstructured.NestedString(ref, "name")
+	gateway.Namespace, _, _ = unstructured.NestedString(ref, "namespace")
+	if gateway.Namespace == "" {
+		gateway.Namespace = routeNamespace
+	}
+
+	return gateway, gateway.Name != ""
+}
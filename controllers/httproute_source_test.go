@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestObject(apiVersion string, kind string, namespace string, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestHTTPRouteSourceObjectHosts(t *testing.T) {
+	gatewayAPIVersion := "gateway.networking.k8s.io/v1beta1"
+
+	c := fake.NewClientBuilder().WithObjects(
+		newTestObject(gatewayAPIVersion, "Gateway", "admin", "public-gateway", map[string]interface{}{
+			"gatewayClassName": "istio",
+		}),
+	).Build()
+
+	route := newTestObject(gatewayAPIVersion, "HTTPRoute", "test-namespace", "test-app", map[string]interface{}{
+		"hostnames": []interface{}{
+			"test-app.sandbox.platform.hmcts.net",
+			"*.sandbox.platform.hmcts.net",
+		},
+		"parentRefs": []interface{}{
+			map[string]interface{}{
+				"name":      "public-gateway",
+				"namespace": "admin",
+			},
+		},
+	})
+
+	tests := []struct {
+		name          string
+		routeSource   *v1beta1.HTTPRouteSource
+		expectedHosts []string
+	}{
+		{
+			name: "no http route source",
+		},
+		{
+			name:          "gateway name",
+			routeSource:   &v1beta1.HTTPRouteSource{Gateways: []string{"public-gateway"}},
+			expectedHosts: []string{"test-app.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:          "gateway namespace and name",
+			routeSource:   &v1beta1.HTTPRouteSource{Gateways: []string{"admin/public-gateway"}},
+			expectedHosts: []string{"test-app.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:        "gateway in another namespace",
+			routeSource: &v1beta1.HTTPRouteSource{Gateways: []string{"test-namespace/public-gateway"}},
+		},
+		{
+			name:          "gateway class",
+			routeSource:   &v1beta1.HTTPRouteSource{GatewayClassName: "istio"},
+			expectedHosts: []string{"test-app.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:        "other gateway class",
+			routeSource: &v1beta1.HTTPRouteSource{GatewayClassName: "cilium"},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source.HTTPRoute = test.routeSource
+
+		hosts, err := HTTPRouteSource.objectHosts(context.TODO(), c, route, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostnames []string
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Host)
+			if host.Kind != "HTTPRoute" || host.Namespace != "test-namespace" || host.Name != "test-app" {
+				t.Errorf("Host %+v not read from the route\nTest: %s\n", host, test.name)
+			}
+		}
+
+		if !reflect.DeepEqual(hostnames, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", hostnames, test.expectedHosts, test.name)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"github.com/hmcts/reply-urls-operator/api/v1alpha1"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
 	azureGraph "github.com/hmcts/reply-urls-operator/controllers/pkg/azure"
 	v1 "k8s.io/api/networking/v1"
 	"k8s.io/utils/strings/slices"
@@ -64,24 +64,32 @@ var _ = Describe("ReplyURLSync Config", func() {
 		domainFilter   = ".*" + testRunID + ".sandbox.platform.hmcts.net"
 		replyURLFilter = ".*" + testRunID + ".sandbox.platform.hmcts.net"
 
-		replyURLSync = &v1alpha1.ReplyURLSync{
+		replyURLSync = &v1beta1.ReplyURLSync{
 			TypeMeta: metav1.TypeMeta{
-				APIVersion: "appregistrations.azure.hmcts.net/v1alpha1",
+				APIVersion: "appregistrations.azure.hmcts.net/v1beta1",
 				Kind:       "ReplyURLSync",
 			},
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      replyURLSyncName,
 				Namespace: replyURLSyncNamespace,
 			},
-			Spec: v1alpha1.ReplyURLSyncSpec{
-				TenantID:           &tenantID,
-				ObjectID:           &objectID,
-				ClientID:           &clientID,
-				DomainFilter:       &domainFilter,
-				ReplyURLFilter:     &replyURLFilter,
-				IngressClassFilter: &ingressClass,
-				ClientSecret: &v1alpha1.ClientSecret{
-					EnvVarClientSecret: &envVarClientSecret,
+			Spec: v1beta1.ReplyURLSyncSpec{
+				Source: v1beta1.SourceSpec{
+					IngressClassFilter: ingressClass,
+				},
+				Target: v1beta1.TargetSpec{
+					ObjectID: objectID,
+				},
+				Credentials: v1beta1.CredentialsSpec{
+					TenantID: tenantID,
+					ClientID: clientID,
+					ClientSecret: v1beta1.ClientSecret{
+						EnvVarClientSecret: envVarClientSecret,
+					},
+				},
+				Filters: v1beta1.FiltersSpec{
+					DomainFilter:   domainFilter,
+					ReplyURLFilter: replyURLFilter,
 				},
 			},
 		}
@@ -139,7 +147,7 @@ var _ = Describe("ReplyURLSync Config", func() {
 			Expect(k8sClient.Create(ctx, replyURLSync)).Should(Succeed())
 
 			replyURLSyncLookupKey := types.NamespacedName{Name: replyURLSyncName, Namespace: replyURLSyncNamespace}
-			createdReplyURLSync := &v1alpha1.ReplyURLSync{}
+			createdReplyURLSync := &v1beta1.ReplyURLSync{}
 
 			// We'll need to retry getting this newly created CronJob, given that creation may not immediately happen.
 			Eventually(func() bool {
@@ -150,11 +158,11 @@ var _ = Describe("ReplyURLSync Config", func() {
 				return true
 			}, timeout, interval).Should(BeTrue())
 			// Let's make sure our Schedule string value was properly converted/handled.
-			Expect(createdReplyURLSync.Spec.ClientID).Should(Equal(&clientID))
-			Expect(createdReplyURLSync.Spec.ObjectID).Should(Equal(&objectID))
-			Expect(createdReplyURLSync.Spec.TenantID).Should(Equal(&tenantID))
-			Expect(createdReplyURLSync.Spec.IngressClassFilter).Should(Equal(&ingressClass))
-			Expect(createdReplyURLSync.Spec.DomainFilter).Should(Equal(&domainFilter))
+			Expect(createdReplyURLSync.Spec.Credentials.ClientID).Should(Equal(clientID))
+			Expect(createdReplyURLSync.Spec.Target.ObjectID).Should(Equal(objectID))
+			Expect(createdReplyURLSync.Spec.Credentials.TenantID).Should(Equal(tenantID))
+			Expect(createdReplyURLSync.Spec.Source.IngressClassFilter).Should(Equal(ingressClass))
+			Expect(createdReplyURLSync.Spec.Filters.DomainFilter).Should(Equal(domainFilter))
 
 		})
 	})
@@ -163,8 +171,8 @@ var _ = Describe("ReplyURLSync Config", func() {
 		It("The app registrations list of urls for the pr should be empty", func() {
 			By("By cleaning up the list")
 			appRegPatchOptions := azureGraph.PatchOptions{
-				IngressHosts: []string{},
-				Syncer:       *replyURLSync,
+				ReplyURLs: azureGraph.ReplyURLs{},
+				Syncer:    *replyURLSync,
 			}
 
 			client, err := azureGraph.CreateClient(&clientSecretCreds)
@@ -172,7 +180,7 @@ var _ = Describe("ReplyURLSync Config", func() {
 				workerLog.Error(err, "Test Error")
 			}
 
-			replyURLS, err := azureGraph.GetReplyURLs(*appRegPatchOptions.Syncer.Spec.ObjectID, client)
+			replyURLS, err := azureGraph.GetReplyURLs(appRegPatchOptions.Syncer.Spec.Target.ObjectID, client)
 			if err != nil {
 				workerLog.Error(err, "Test Error")
 			}
@@ -184,14 +192,14 @@ var _ = Describe("ReplyURLSync Config", func() {
 				}
 			}
 
-			err = azureGraph.PatchAppReplyURLs(*appRegPatchOptions.Syncer.Spec.ObjectID, cleanedReplyURLS, client)
+			err = azureGraph.PatchAppReplyURLs(appRegPatchOptions.Syncer.Spec.Target.ObjectID, cleanedReplyURLS, client)
 			if err != nil {
 				workerLog.Error(err, "Test Error")
 			}
 
 			Eventually(func() []string {
 				var foundURLS = make([]string, 0)
-				replyURLS, err := azureGraph.GetReplyURLs(*appRegPatchOptions.Syncer.Spec.ObjectID, client)
+				replyURLS, err := azureGraph.GetReplyURLs(appRegPatchOptions.Syncer.Spec.Target.ObjectID, client)
 				if err != nil {
 					workerLog.Error(err, "Test Error")
 				}
@@ -227,6 +235,9 @@ var _ = Describe("ReplyURLSync Config", func() {
 						Rules: []v1.IngressRule{
 							{Host: testIngress.host},
 						},
+						TLS: []v1.IngressTLS{
+							{Hosts: []string{testIngress.host}},
+						},
 					},
 				}
 				Expect(k8sClient.Create(ctx, ingress)).Should(Succeed())
@@ -0,0 +1,95 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	azureGraph "github.com/hmcts/reply-urls-operator/controllers/pkg/azure"
+	v1 "k8s.io/api/networking/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const ingressClassFilterField = "spec.source.ingressClassFilter"
+
+var (
+	workerLog = ctrl.Log
+)
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses/status,verbs=get
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingressclasses,verbs=get;list;watch
+
+// indexIngressFields adds the indexes used to find the ReplyURLSyncs of an ingress
+func indexIngressFields(mgr ctrl.Manager) error {
+	replyURLSync := &v1beta1.ReplyURLSync{}
+
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), replyURLSync, ingressClassFilterField, indexIngressClassNames); err != nil {
+		return err
+	}
+
+	return mgr.GetFieldIndexer().IndexField(context.Background(), replyURLSync, ingressControllerField, indexIngressController)
+}
+
+// indexIngressClassNames indexes every ingress class of a sync so it's found by any of them
+func indexIngressClassNames(rawObj client.Object) []string {
+	ingressClasses := rawObj.(*v1beta1.ReplyURLSync).Spec.Source.IngressClassNames()
+
+	if ingressClasses == nil {
+		return []string{}
+	}
+	return ingressClasses
+}
+
+func indexIngressController(rawObj client.Object) []string {
+	ingressController := rawObj.(*v1beta1.ReplyURLSync).Spec.Source.IngressController
+
+	if ingressController == "" {
+		return []string{}
+	}
+	return []string{ingressController}
+}
+
+// ingressSource reads hosts from the rules of ingresses
+type ingressSource struct{}
+
+// listHosts returns the hosts of the ingresses selected by the ingress classes or ingress controller of the sync
+func (ingressSource) listHosts(ctx context.Context, c client.Client, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	if syncer.Spec.Source.IngressClassNames() == nil && syncer.Spec.Source.IngressController == "" {
+		return nil, nil
+	}
+
+	classes, err := listIngressClasses(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	ingressList := v1.IngressList{}
+
+	if err := c.List(ctx, &ingressList); err != nil {
+		workerLog.Error(err, "Couldn't list ingress")
+		return nil, err
+	}
+
+	for _, ingress := range ingressList.Items {
+		if classes.selects(ingress, syncer.Spec.Source) {
+			hosts = append(hosts, azureGraph.IngressRuleHosts(ingress)...)
+		}
+	}
+	return hosts, nil
+}
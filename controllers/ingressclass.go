@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-openapi/swag"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	v1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ingressControllerField = "spec.source.ingressController"
+
+	// defaultIngressClassAnnotation marks the IngressClass that ingresses without a class belong to
+	defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+	// ingressClassAnnotation is the legacy annotation used to set the class of an ingress
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+/*
+ingressClasses resolves the class of ingresses the same way ingress controllers decide
+which ingresses they own, using the IngressClasses on the cluster.
+*/
+type ingressClasses struct {
+	// controllers are the controllers of the IngressClasses by class name
+	controllers map[string]string
+	// defaultClass is the class of ingresses without one, empty when there isn't exactly one default class
+	defaultClass string
+}
+
+// listIngressClasses returns the IngressClasses on the cluster
+func listIngressClasses(ctx context.Context, c client.Client) (classes ingressClasses, err error) {
+	ingressClassList := v1.IngressClassList{}
+
+	if err = c.List(ctx, &ingressClassList); err != nil {
+		return classes, err
+	}
+
+	classes.controllers = map[string]string{}
+	defaultClasses := 0
+
+	for _, ingressClass := range ingressClassList.Items {
+		classes.controllers[ingressClass.Name] = ingressClass.Spec.Controller
+
+		if ingressClass.Annotations[defaultIngressClassAnnotation] == "true" {
+			classes.defaultClass = ingressClass.Name
+			defaultClasses++
+		}
+	}
+
+	// Ingresses without a class aren't given one when more than one class is marked as the default
+	if defaultClasses > 1 {
+		classes.defaultClass = ""
+	}
+
+	return classes, nil
+}
+
+// className returns the class of an ingress, which is the default class when it hasn't set one
+func (classes ingressClasses) className(ingress v1.Ingress) string {
+	if ingress.Spec.IngressClassName != nil {
+		return *ingress.Spec.IngressClassName
+	} else if ingressClass := ingress.Annotations[ingressClassAnnotation]; ingressClass != "" {
+		return ingressClass
+	}
+
+	return classes.defaultClass
+}
+
+// controller returns the controller of the class of an ingress, empty if its IngressClass doesn't exist
+func (classes ingressClasses) controller(ingress v1.Ingress) string {
+	return classes.controllers[classes.className(ingress)]
+}
+
+// selects reports whether an ingress is selected by its class or the controller of its class
+func (classes ingressClasses) selects(ingress v1.Ingress, source v1beta1.SourceSpec) bool {
+	if className := classes.className(ingress); className != "" && swag.ContainsStrings(source.IngressClassNames(), className) {
+		return true
+	}
+
+	controller := classes.controller(ingress)
+	return controller != "" && controller == source.IngressController
+}
+
+// listIngressReplyURLSync returns the ReplyURLSyncs that select an ingress by its class or the controller of its class
+func listIngressReplyURLSync(ctx context.Context, c client.Client, classes ingressClasses, ingress v1.Ingress) (replyURLSyncs []v1beta1.ReplyURLSync, err error) {
+	selectors := []struct {
+		field string
+		value string
+	}{
+		{field: ingressClassFilterField, value: classes.className(ingress)},
+		{field: ingressControllerField, value: classes.controller(ingress)},
+	}
+
+	seen := map[client.ObjectKey]bool{}
+	for _, selector := range selectors {
+		if selector.value == "" {
+			continue
+		}
+
+		replyURLSyncList := &v1beta1.ReplyURLSyncList{}
+		if err = c.List(ctx, replyURLSyncList, client.MatchingFields{selector.field: selector.value}); err != nil {
+			return nil, err
+		}
+
+		for _, replyURLSync := range replyURLSyncList.Items {
+			if key := client.ObjectKeyFromObject(&replyURLSync); !seen[key] {
+				seen[key] = true
+				replyURLSyncs = append(replyURLSyncs, replyURLSync)
+			}
+		}
+	}
+	return replyURLSyncs, nil
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIngressSourceListHosts(t *testing.T) {
+	newIngressClass := func(name string, controller string, isDefault bool) *v1.IngressClass {
+		ingressClass := &v1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1.IngressClassSpec{Controller: controller},
+		}
+		if isDefault {
+			ingressClass.Annotations = map[string]string{defaultIngressClassAnnotation: "true"}
+		}
+		return ingressClass
+	}
+
+	newIngress := func(name string, className string, annotations map[string]string) *v1.Ingress {
+		ingress := &v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "test-namespace",
+				Annotations: annotations,
+			},
+			Spec: v1.IngressSpec{
+				Rules: []v1.IngressRule{
+					{Host: name + ".sandbox.platform.hmcts.net"},
+				},
+			},
+		}
+		if className != "" {
+			ingress.Spec.IngressClassName = &className
+		}
+		return ingress
+	}
+
+	objects := []client.Object{
+		newIngress("test-app-1", "traefik", nil),
+		newIngress("test-app-2", "", map[string]string{ingressClassAnnotation: "traefik-private"}),
+		newIngress("test-app-3", "", nil),
+		newIngress("test-app-4", "nginx", nil),
+	}
+
+	tests := []struct {
+		name           string
+		ingressClasses []client.Object
+		source         v1beta1.SourceSpec
+		expectedHosts  []string
+	}{
+		{
+			name:   "no ingress class or controller",
+			source: v1beta1.SourceSpec{},
+		},
+		{
+			name:           "ingress class with a default class",
+			ingressClasses: []client.Object{newIngressClass("traefik", "traefik.io/ingress-controller", true)},
+			source:         v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			expectedHosts:  []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-3.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:          "ingress class without a default class",
+			source:        v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			expectedHosts: []string{"test-app-1.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:          "ingress classes",
+			source:        v1beta1.SourceSpec{IngressClassFilter: "traefik", IngressClasses: []string{"traefik-private", "traefik"}},
+			expectedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+		},
+		{
+			name: "more than one default class",
+			ingressClasses: []client.Object{
+				newIngressClass("traefik", "traefik.io/ingress-controller", true),
+				newIngressClass("nginx", "k8s.io/ingress-nginx", true),
+			},
+			source:        v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			expectedHosts: []string{"test-app-1.sandbox.platform.hmcts.net"},
+		},
+		{
+			name: "ingress controller",
+			ingressClasses: []client.Object{
+				newIngressClass("traefik", "traefik.io/ingress-controller", false),
+				newIngressClass("traefik-private", "traefik.io/ingress-controller", false),
+				newIngressClass("nginx", "k8s.io/ingress-nginx", true),
+			},
+			source:        v1beta1.SourceSpec{IngressController: "traefik.io/ingress-controller"},
+			expectedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+		},
+	}
+
+	for _, test := range tests {
+		c := fake.NewClientBuilder().WithObjects(append(test.ingressClasses, objects...)...).Build()
+
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source = test.source
+
+		hosts, err := ingressSource{}.listHosts(context.TODO(), c, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostnames []string
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Host)
+		}
+
+		if !reflect.DeepEqual(hostnames, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", hostnames, test.expectedHosts, test.name)
+		}
+	}
+}
+
+func TestIngressClassesSelects(t *testing.T) {
+	traefik := "traefik"
+
+	classes := ingressClasses{
+		controllers: map[string]string{
+			"traefik":         "traefik.io/ingress-controller",
+			"traefik-private": "traefik.io/ingress-controller",
+			"nginx":           "k8s.io/ingress-nginx",
+		},
+		defaultClass: "nginx",
+	}
+
+	tests := []struct {
+		name     string
+		ingress  v1.Ingress
+		source   v1beta1.SourceSpec
+		expected bool
+	}{
+		{
+			name:     "ingress class name",
+			ingress:  v1.Ingress{Spec: v1.IngressSpec{IngressClassName: &traefik}},
+			source:   v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			expected: true,
+		},
+		{
+			name: "ingress class annotation",
+			ingress: v1.Ingress{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{ingressClassAnnotation: "traefik"},
+			}},
+			source:   v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			expected: true,
+		},
+		{
+			name: "other ingress class annotation",
+			ingress: v1.Ingress{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{ingressClassAnnotation: "wrong-traefik"},
+			}},
+			source: v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+		},
+		{
+			name: "ingress class name before the annotation",
+			ingress: v1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{ingressClassAnnotation: "nginx"}},
+				Spec:       v1.IngressSpec{IngressClassName: &traefik},
+			},
+			source:   v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			expected: true,
+		},
+		{
+			name:    "default class",
+			ingress: v1.Ingress{},
+			source:  v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+		},
+		{
+			name:     "one of the ingress classes",
+			ingress:  v1.Ingress{},
+			source:   v1beta1.SourceSpec{IngressClasses: []string{"traefik", "nginx"}},
+			expected: true,
+		},
+		{
+			name: "ingress controller",
+			ingress: v1.Ingress{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{ingressClassAnnotation: "traefik-private"},
+			}},
+			source:   v1beta1.SourceSpec{IngressController: "traefik.io/ingress-controller"},
+			expected: true,
+		},
+		{
+			name: "class without an IngressClass",
+			ingress: v1.Ingress{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{ingressClassAnnotation: "haproxy"},
+			}},
+			source: v1beta1.SourceSpec{IngressController: "traefik.io/ingress-controller"},
+		},
+	}
+
+	for _, test := range tests {
+		if result := classes.selects(test.ingress, test.source); result != test.expected {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", result, test.expected, test.name)
+		}
+	}
+}
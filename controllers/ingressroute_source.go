@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=traefik.io,resources=ingressroutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=traefik.containo.us,resources=ingressroutes,verbs=get;list;watch
+
+var (
+	// IngressRouteSource reads hosts from the match rules of Traefik IngressRoutes
+	IngressRouteSource ObjectSource = ingressRouteSource{group: "traefik.io"}
+	// LegacyIngressRouteSource reads hosts from IngressRoutes in the API group used before Traefik 2.10
+	LegacyIngressRouteSource ObjectSource = ingressRouteSource{group: "traefik.containo.us"}
+
+	// hostMatcherRegex matches Host matchers and their arguments, negated matchers are matched so they can be skipped
+	hostMatcherRegex = regexp.MustCompile("(!?)\\bHost\\(([^)]*)\\)")
+	// pathMatcherRegex matches the first argument of Path and PathPrefix matchers
+	pathMatcherRegex = regexp.MustCompile("\\bPath(?:Prefix)?\\(\\s*[`\"']([^`\"']*)[`\"']")
+	// matcherArgRegex matches the quoted arguments of a matcher
+	matcherArgRegex = regexp.MustCompile("[`\"']([^`\"']+)[`\"']")
+)
+
+type ingressRouteSource struct {
+	group string
+}
+
+func (source ingressRouteSource) groupKind() schema.GroupKind {
+	return schema.GroupKind{Group: source.group, Kind: "IngressRoute"}
+}
+
+func (ingressRouteSource) usedBy(syncer v1beta1.ReplyURLSync) bool {
+	return syncer.Spec.Source.IngressRoute != nil
+}
+
+// objectHosts returns the hosts in the match rules of a route selected by the sync
+func (ingressRouteSource) objectHosts(_ context.Context, _ client.Client, route *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	routeSource := syncer.Spec.Source.IngressRoute
+	if routeSource == nil {
+		return nil, nil
+	}
+
+	if routeSource.IngressClass != "" && route.GetAnnotations()["kubernetes.io/ingress.class"] != routeSource.IngressClass {
+		return nil, nil
+	}
+
+	if len(routeSource.EntryPoints) > 0 {
+		entryPoints, _, err := unstructured.NestedStringSlice(route.Object, "spec", "entryPoints")
+		if err != nil {
+			return nil, err
+		}
+
+		selected := false
+		for _, entryPoint := range entryPoints {
+			selected = selected || swag.ContainsStrings(routeSource.EntryPoints, entryPoint)
+		}
+		if !selected {
+			return nil, nil
+		}
+	}
+
+	routes, _, err := unstructured.NestedSlice(route.Object, "spec", "routes")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, routeRule := range routes {
+		rule, ok := routeRule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		match, _, _ := unstructured.NestedString(rule, "match")
+		path := matchPath(match)
+
+		for _, hostname := range matchHosts(match) {
+			host := objectHost(route, hostname)
+			host.Path = path
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts, nil
+}
+
+// matchHosts returns the hosts of the Host matchers in a Traefik match rule, hosts of negated matchers are ignored
+func matchHosts(match string) (hosts []string) {
+	for _, hostMatcher := range hostMatcherRegex.FindAllStringSubmatch(match, -1) {
+		if hostMatcher[1] == "!" {
+			continue
+		}
+
+		for _, arg := range matcherArgRegex.FindAllStringSubmatch(hostMatcher[2], -1) {
+			hosts = append(hosts, strings.TrimSpace(arg[1]))
+		}
+	}
+	return hosts
+}
+
+// matchPath returns the path of the first Path or PathPrefix matcher in a Traefik match rule without a trailing slash
+func matchPath(match string) string {
+	if pathMatcher := pathMatcherRegex.FindStringSubmatch(match); pathMatcher != nil {
+		return strings.TrimSuffix(pathMatcher[1], "/")
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIngressRouteSourceObjectHosts(t *testing.T) {
+	route := newTestObject("traefik.io/v1alpha1", "IngressRoute", "test-namespace", "test-app", map[string]interface{}{
+		"entryPoints": []interface{}{"websecure"},
+		"routes": []interface{}{
+			map[string]interface{}{
+				"match": "Host(`test-app-1.sandbox.platform.hmcts.net`) && PathPrefix(`/app/`)",
+			},
+			map[string]interface{}{
+				"match": "(Host(`test-app-2.sandbox.platform.hmcts.net`, \"test-app-3.sandbox.platform.hmcts.net\") || HostRegexp(`{subdomain:[a-z]+}.platform.hmcts.net`)) && !Host(`test-app-4.sandbox.platform.hmcts.net`)",
+			},
+		},
+	})
+	route.SetAnnotations(map[string]string{"kubernetes.io/ingress.class": "traefik"})
+
+	allHosts := []string{
+		"test-app-1.sandbox.platform.hmcts.net/app",
+		"test-app-2.sandbox.platform.hmcts.net",
+		"test-app-3.sandbox.platform.hmcts.net",
+	}
+
+	tests := []struct {
+		name          string
+		routeSource   *v1beta1.IngressRouteSource
+		expectedHosts []string
+	}{
+		{
+			name: "no ingress route source",
+		},
+		{
+			name:          "every route",
+			routeSource:   &v1beta1.IngressRouteSource{},
+			expectedHosts: allHosts,
+		},
+		{
+			name:          "ingress class and entry point",
+			routeSource:   &v1beta1.IngressRouteSource{IngressClass: "traefik", EntryPoints: []string{"web", "websecure"}},
+			expectedHosts: allHosts,
+		},
+		{
+			name:        "other ingress class",
+			routeSource: &v1beta1.IngressRouteSource{IngressClass: "traefik-private"},
+		},
+		{
+			name:        "other entry point",
+			routeSource: &v1beta1.IngressRouteSource{EntryPoints: []string{"web"}},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source.IngressRoute = test.routeSource
+
+		hosts, err := IngressRouteSource.objectHosts(context.TODO(), fake.NewClientBuilder().Build(), route, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostPaths []string
+		for _, host := range hosts {
+			hostPaths = append(hostPaths, host.Host+host.Path)
+		}
+
+		if !reflect.DeepEqual(hostPaths, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", hostPaths, test.expectedHosts, test.name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	knativeServingGroup = "serving.knative.dev"
+	// knativeVisibilityLabel is set to cluster-local on Knative Services that are only reachable inside the cluster
+	knativeVisibilityLabel = "networking.knative.dev/visibility"
+)
+
+//+kubebuilder:rbac:groups=serving.knative.dev,resources=services;domainmappings,verbs=get;list;watch
+
+var (
+	// KnativeServiceSource reads hosts from the URLs of Knative Services
+	KnativeServiceSource ObjectSource = knativeSource{kind: "Service"}
+	// DomainMappingSource reads hosts from the URLs of Knative DomainMappings
+	DomainMappingSource ObjectSource = knativeSource{kind: "DomainMapping"}
+)
+
+type knativeSource struct {
+	kind string
+}
+
+func (source knativeSource) groupKind() schema.GroupKind {
+	return schema.GroupKind{Group: knativeServingGroup, Kind: source.kind}
+}
+
+func (source knativeSource) usedBy(syncer v1beta1.ReplyURLSync) bool {
+	knative := syncer.Spec.Source.Knative
+	if knative == nil {
+		return false
+	}
+	return (source.kind == "Service" && knative.Services) || (source.kind == "DomainMapping" && knative.DomainMappings)
+}
+
+// objectHosts returns the host of the URL Knative has given a resource, resources only reachable inside the cluster have none
+func (source knativeSource) objectHosts(_ context.Context, _ client.Client, obj *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) ([]v1beta1.Host, error) {
+	if !source.usedBy(syncer) {
+		return nil, nil
+	}
+
+	if obj.GetLabels()[knativeVisibilityLabel] == "cluster-local" {
+		return nil, nil
+	}
+
+	// The URL is only set once Knative has made the resource ready
+	statusURL, _, _ := unstructured.NestedString(obj.Object, "status", "url")
+	if statusURL == "" {
+		return nil, nil
+	}
+
+	parsedURL, err := url.Parse(statusURL)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname := parsedURL.Hostname()
+	if hostname == "" || strings.HasSuffix(hostname, ".svc.cluster.local") {
+		return nil, nil
+	}
+
+	return []v1beta1.Host{objectHost(obj, hostname)}, nil
+}
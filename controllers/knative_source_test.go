@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKnativeSourceObjectHosts(t *testing.T) {
+	newKnativeObject := func(kind, statusURL string) *unstructured.Unstructured {
+		obj := newTestObject("serving.knative.dev/v1", kind, "test-namespace", "test-app", map[string]interface{}{})
+		if statusURL != "" {
+			_ = unstructured.SetNestedField(obj.Object, statusURL, "status", "url")
+		}
+		return obj
+	}
+
+	service := newKnativeObject("Service", "https://test-app.test-namespace.sandbox.platform.hmcts.net")
+
+	clusterLocalService := newKnativeObject("Service", "http://test-app.test-namespace.svc.cluster.local")
+	clusterLocalService.SetLabels(map[string]string{knativeVisibilityLabel: "cluster-local"})
+
+	domainMapping := newKnativeObject("DomainMapping", "https://test-app.sandbox.platform.hmcts.net")
+
+	tests := []struct {
+		name          string
+		source        ObjectSource
+		obj           *unstructured.Unstructured
+		knative       *v1beta1.KnativeSource
+		expectedHosts []string
+	}{
+		{
+			name:   "no knative source",
+			source: KnativeServiceSource,
+			obj:    service,
+		},
+		{
+			name:          "service",
+			source:        KnativeServiceSource,
+			obj:           service,
+			knative:       &v1beta1.KnativeSource{Services: true},
+			expectedHosts: []string{"test-app.test-namespace.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:    "cluster local service",
+			source:  KnativeServiceSource,
+			obj:     clusterLocalService,
+			knative: &v1beta1.KnativeSource{Services: true},
+		},
+		{
+			name:    "service not ready",
+			source:  KnativeServiceSource,
+			obj:     newKnativeObject("Service", ""),
+			knative: &v1beta1.KnativeSource{Services: true},
+		},
+		{
+			name:    "services not synced",
+			source:  KnativeServiceSource,
+			obj:     service,
+			knative: &v1beta1.KnativeSource{DomainMappings: true},
+		},
+		{
+			name:          "domain mapping",
+			source:        DomainMappingSource,
+			obj:           domainMapping,
+			knative:       &v1beta1.KnativeSource{DomainMappings: true},
+			expectedHosts: []string{"test-app.sandbox.platform.hmcts.net"},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source.Knative = test.knative
+
+		hosts, err := test.source.objectHosts(context.TODO(), fake.NewClientBuilder().Build(), test.obj, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostnames []string
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Host)
+		}
+
+		if !reflect.DeepEqual(hostnames, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", hostnames, test.expectedHosts, test.name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+/*
+ObjectSource is a source of hosts read from a kind of custom resource. The resources are
+read as unstructured objects so the operator doesn't depend on the API of every project
+it reads hosts from, and runs on clusters that don't have their CRDs installed.
+*/
+type ObjectSource interface {
+	// groupKind is the group and kind of the resources hosts are read from
+	groupKind() schema.GroupKind
+	// usedBy reports whether syncer reads hosts from the source
+	usedBy(syncer v1beta1.ReplyURLSync) bool
+	// objectHosts returns the hosts of obj that syncer reads, none if syncer doesn't use the source or select obj
+	objectHosts(ctx context.Context, c client.Client, obj *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) ([]v1beta1.Host, error)
+}
+
+/*
+dependentSource is an ObjectSource whose hosts also depend on other kinds of resource, such as
+the Gateways HTTPRoutes are attached to, so changes to them resync the ReplyURLSyncs using it
+*/
+type dependentSource interface {
+	ObjectSource
+	// dependencies are the groups and kinds of the other resources the hosts depend on
+	dependencies() []schema.GroupKind
+}
+
+// versionedSource is an ObjectSource that reads a version of its resources other than the preferred one
+type versionedSource interface {
+	version() string
+}
+
+// watchedSource is an ObjectSource being watched, with the version of its resources that is read
+type watchedSource struct {
+	source ObjectSource
+	gvk    schema.GroupVersionKind
+}
+
+// listHosts returns the hosts of every resource of the source that syncer reads, without listing them if it doesn't use the source
+func (watched *watchedSource) listHosts(ctx context.Context, c client.Client, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	if !watched.source.usedBy(syncer) {
+		return nil, nil
+	}
+
+	objList := &unstructured.UnstructuredList{}
+	objList.SetGroupVersionKind(watched.gvk.GroupVersion().WithKind(watched.gvk.Kind + "List"))
+
+	if err = c.List(ctx, objList); err != nil {
+		return nil, err
+	}
+
+	for i := range objList.Items {
+		objHosts, err := watched.source.objectHosts(ctx, c, &objList.Items[i], syncer)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, objHosts...)
+	}
+	return hosts, nil
+}
+
+func (watched *watchedSource) newObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(watched.gvk)
+	return obj
+}
+
+// objectHost returns a host read from obj
+func objectHost(obj *unstructured.Unstructured, host string) v1beta1.Host {
+	return v1beta1.Host{
+		Host:        host,
+		Kind:        obj.GetKind(),
+		Namespace:   obj.GetNamespace(),
+		Name:        obj.GetName(),
+		Labels:      obj.GetLabels(),
+		Annotations: obj.GetAnnotations(),
+	}
+}
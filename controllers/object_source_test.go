@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// listCountingClient is a client that counts the lists read through it
+type listCountingClient struct {
+	client.Client
+	lists int
+}
+
+func (c *listCountingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists++
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestWatchedSourceListHosts(t *testing.T) {
+	service := newTestObject("serving.knative.dev/v1", "Service", "test-namespace", "test-app", map[string]interface{}{})
+	service.Object["status"] = map[string]interface{}{"url": "https://test-app.sandbox.platform.hmcts.net"}
+
+	watched := &watchedSource{source: KnativeServiceSource, gvk: service.GroupVersionKind()}
+
+	tests := []struct {
+		name          string
+		source        v1beta1.SourceSpec
+		expectedHosts []string
+		expectedLists int
+	}{
+		{
+			name:          "source used",
+			source:        v1beta1.SourceSpec{Knative: &v1beta1.KnativeSource{Services: true}},
+			expectedHosts: []string{"test-app.sandbox.platform.hmcts.net"},
+			expectedLists: 1,
+		},
+		{
+			name:   "other kind used",
+			source: v1beta1.SourceSpec{Knative: &v1beta1.KnativeSource{DomainMappings: true}},
+		},
+		{
+			name: "source not used",
+		},
+	}
+
+	for _, test := range tests {
+		c := &listCountingClient{Client: fake.NewClientBuilder().WithObjects(service.DeepCopy()).Build()}
+
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source = test.source
+
+		hosts, err := watched.listHosts(context.TODO(), c, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostnames []string
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Host)
+		}
+
+		if !reflect.DeepEqual(hostnames, test.expectedHosts) || c.lists != test.expectedLists {
+			t.Errorf("Result %v %d lists not equal to the expected result %v %d lists\nTest: %s\n",
+				hostnames, c.lists, test.expectedHosts, test.expectedLists, test.name)
+		}
+	}
+}
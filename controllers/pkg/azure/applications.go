@@ -3,10 +3,10 @@ package azureGraph
 import (
 	"context"
 	"github.com/go-openapi/swag"
-	"github.com/hmcts/reply-urls-operator/api/v1alpha1"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
 	msgraphsdk "github.com/microsoftgraph/msgraph-sdk-go"
 	graph "github.com/microsoftgraph/msgraph-sdk-go/models"
-	v1 "k8s.io/api/networking/v1"
+	"reflect"
 	"regexp"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -21,21 +21,67 @@ func getApplication(appId string, graphClient *msgraphsdk.GraphServiceClient) (a
 }
 
 func GetReplyURLs(appId string, graphClient *msgraphsdk.GraphServiceClient) (replyURLs []string, err error) {
+	return GetPlatformReplyURLs(appId, v1beta1.PlatformWeb, graphClient)
+}
+
+// GetPlatformReplyURLs returns the redirect URIs of an application on a platform
+func GetPlatformReplyURLs(appId string, platform v1beta1.Platform, graphClient *msgraphsdk.GraphServiceClient) (replyURLs []string, err error) {
 	appObject, err := getApplication(appId, graphClient)
 	if err != nil {
 		return nil, err
 	}
-	replyURLs = appObject.GetWeb().GetRedirectUris()
-	return replyURLs, nil
+	return platformReplyURLs(appObject, platform), nil
+}
+
+// platformReplyURLs returns the redirect URIs of a platform, platforms that haven't been set up have none
+func platformReplyURLs(appObject graph.Applicationable, platform v1beta1.Platform) []string {
+	switch platform {
+	case v1beta1.PlatformSPA:
+		if spa := appObject.GetSpa(); spa != nil {
+			return spa.GetRedirectUris()
+		}
+	case v1beta1.PlatformPublicClient:
+		if publicClient := appObject.GetPublicClient(); publicClient != nil {
+			return publicClient.GetRedirectUris()
+		}
+	default:
+		if web := appObject.GetWeb(); web != nil {
+			return web.GetRedirectUris()
+		}
+	}
+	return nil
 }
 
 func PatchAppReplyURLs(appId string, urls []string, graphClient *msgraphsdk.GraphServiceClient) error {
+	return PatchPlatformReplyURLs(appId, v1beta1.PlatformWeb, urls, graphClient)
+}
+
+// PatchPlatformReplyURLs sets the redirect URIs of an application on a platform, leaving the other platforms as they are
+func PatchPlatformReplyURLs(appId string, platform v1beta1.Platform, urls []string, graphClient *msgraphsdk.GraphServiceClient) error {
+	return PatchReplyURLs(appId, ReplyURLs{platform: urls}, graphClient)
+}
+
+// PatchReplyURLs sets the redirect URIs of an application on every platform in replyURLs with a single request
+func PatchReplyURLs(appId string, replyURLs ReplyURLs, graphClient *msgraphsdk.GraphServiceClient) error {
 	// Patch Application
 	requestBody := graph.NewApplication()
-	app := graph.NewWebApplication()
 
-	app.SetRedirectUris(urls)
-	requestBody.SetWeb(app)
+	for platform, urls := range replyURLs {
+		switch platform {
+		case v1beta1.PlatformSPA:
+			app := graph.NewSpaApplication()
+			app.SetRedirectUris(urls)
+			requestBody.SetSpa(app)
+		case v1beta1.PlatformPublicClient:
+			app := graph.NewPublicClientApplication()
+			app.SetRedirectUris(urls)
+			requestBody.SetPublicClient(app)
+		default:
+			app := graph.NewWebApplication()
+			app.SetRedirectUris(urls)
+			requestBody.SetWeb(app)
+		}
+	}
 
 	_, err := graphClient.ApplicationsById(appId).Patch(context.TODO(), requestBody, nil)
 
@@ -46,107 +92,301 @@ func PatchAppReplyURLs(appId string, urls []string, graphClient *msgraphsdk.Grap
 	return nil
 }
 
-func PatchAppRegistration(creds ClientSecretCredentials, patchOptions PatchOptions) (removedURLS []string, err error) {
-	var (
-		newRedirectURLS []string
+// maxSyncAttempts is how many times the reply URLs of an app registration are synced when they keep being changed by another writer
+const maxSyncAttempts = 3
 
-		syncer                 = patchOptions.Syncer
-		syncSpec               = syncer.Spec
-		syncerFullResourceName = syncer.Name
-		replyURLFilter         = syncSpec.ReplyURLFilter
-	)
+/*
+SyncAppRegistration converges the redirect URIs of an app registration with the reply URLs
+managed by a sync. The changes to every platform are worked out from a single read of the
+app registration and written with a single patch, which is skipped when nothing has changed.
+The number of conflicts with other writers seen is returned with the changes made.
+*/
+func SyncAppRegistration(appRegistrations AppRegistrations, patchOptions PatchOptions) (SyncResult, error) {
+	syncSpec := patchOptions.Syncer.Spec
 
-	azureAppClient, err := CreateClient(&creds)
-	if err != nil {
-		return nil, err
+	if err := checkObjectID(patchOptions.Syncer); err != nil {
+		return SyncResult{}, err
 	}
 
-	if syncSpec.ObjectID == nil {
-		fnfErr := FieldNotFoundError{
-			Field:    ".spec.objectID",
-			Resource: syncerFullResourceName,
+	return updateAppReplyURLs(syncSpec.Target.ObjectID, appRegistrations, func(currentURLs ReplyURLs) (ReplyURLs, SyncResult, error) {
+		return diffReplyURLs(currentURLs, patchOptions.ReplyURLs, patchOptions.Syncer.Status.OwnedURLs,
+			syncSpec.Target.Platform, syncSpec.Filters.ReplyURLFilter, syncSpec.UnmanagedURLPolicy)
+	})
+}
+
+/*
+RemoveAppReplyURLs removes reply URLs from the redirect URIs of an app registration with a
+single patch, leaving every other redirect URI as it is. The number of conflicts with other
+writers seen is returned with the reply URLs that were removed.
+*/
+func RemoveAppReplyURLs(appRegistrations AppRegistrations, patchOptions PatchOptions) (removedURLs []string, conflicts int, err error) {
+	if err := checkObjectID(patchOptions.Syncer); err != nil {
+		return nil, 0, err
+	}
+
+	result, err := updateAppReplyURLs(patchOptions.Syncer.Spec.Target.ObjectID, appRegistrations, func(currentURLs ReplyURLs) (ReplyURLs, SyncResult, error) {
+		patchURLs, removedURLs := removeReplyURLs(currentURLs, patchOptions.ReplyURLs)
+		return patchURLs, SyncResult{RemovedURLs: removedURLs}, nil
+	})
+	return result.RemovedURLs, result.Conflicts, err
+}
+
+// removeReplyURLs works out the redirect URIs of the platforms of an app registration that change when replyURLs are removed
+func removeReplyURLs(currentURLs ReplyURLs, replyURLs ReplyURLs) (patchURLs ReplyURLs, removedURLs []string) {
+	patchURLs = ReplyURLs{}
+
+	for _, platform := range v1beta1.Platforms {
+		var platformRemovedURLs []string
+		newRedirectURLs := []string{}
+
+		for _, url := range currentURLs[platform] {
+			if swag.ContainsStrings(replyURLs[platform], url) {
+				platformRemovedURLs = append(platformRemovedURLs, url)
+			} else {
+				newRedirectURLs = append(newRedirectURLs, url)
+			}
+		}
+
+		if len(platformRemovedURLs) > 0 {
+			patchURLs[platform] = newRedirectURLs
+			removedURLs = append(removedURLs, platformRemovedURLs...)
 		}
-		return nil, fnfErr
 	}
+	return patchURLs, removedURLs
+}
 
-	urls, err := GetReplyURLs(*syncSpec.ObjectID, azureAppClient)
+// checkObjectID returns a FieldNotFoundError if the sync doesn't have the object id of its app registration
+func checkObjectID(syncer v1beta1.ReplyURLSync) error {
+	if syncer.Spec.Target.ObjectID == "" {
+		return FieldNotFoundError{
+			Field:    ".spec.target.objectID",
+			Resource: syncer.Name,
+		}
+	}
+	return nil
+}
+
+/*
+updateAppReplyURLs patches the redirect URIs of an app registration with the changes diff works
+out from its current redirect URIs. Graph doesn't support conditional writes to applications,
+so the redirect URIs are read again just before writing and compared with the ones the changes
+were worked out from. If another writer has changed them the changes are worked out again, up
+to maxSyncAttempts times before a ConflictError is returned. This narrows the window in which a
+change by another writer can be overwritten to the time between the last read and the patch,
+it can't close it.
+*/
+func updateAppReplyURLs(appId string, appRegistrations AppRegistrations, diff func(currentURLs ReplyURLs) (patchURLs ReplyURLs, result SyncResult, err error)) (SyncResult, error) {
+	currentURLs, err := appRegistrations.GetReplyURLs(appId)
 	if err != nil {
-		return nil, err
+		return SyncResult{}, err
 	}
 
-	for _, url := range urls {
-		if swag.ContainsStrings(patchOptions.IngressHosts, url) {
-			newRedirectURLS = append(newRedirectURLS, url)
-		} else {
-			/*
-				If a replyURL filter isn't set, delete all reply urls that do not
-				exist as an ingress host on the cluster
-
-				If it is set and the url matches with the filter delete it
-				If it is set and the url doesn't match do not delete it
-			*/
-			if replyURLFilter == nil {
-				removedURLS = append(removedURLS, url)
-			} else {
-				if matched, err := regexp.MatchString(*replyURLFilter, url); err != nil {
-					return nil, err
-				} else if matched {
-					removedURLS = append(removedURLS, url)
-				} else {
-					newRedirectURLS = append(newRedirectURLS, url)
-				}
+	conflicts := 0
+	for attempt := 1; ; attempt++ {
+		patchURLs, result, err := diff(currentURLs)
+		if err != nil {
+			return SyncResult{Conflicts: conflicts}, err
+		}
+
+		// Nothing to write, but unmanaged reply URLs are still reported and the owned ones recorded
+		if len(patchURLs) == 0 {
+			result.Conflicts = conflicts
+			return result, nil
+		}
+
+		latestURLs, err := appRegistrations.GetReplyURLs(appId)
+		if err != nil {
+			return SyncResult{Conflicts: conflicts}, err
+		}
+
+		if changed := changedPlatforms(currentURLs, latestURLs, patchURLs); len(changed) > 0 {
+			conflicts++
+			ctrl.Log.Info("Reply URLs changed by another writer while syncing",
+				"object id", appId, "platforms", changed, "attempt", attempt)
+
+			if attempt == maxSyncAttempts {
+				return SyncResult{Conflicts: conflicts}, ConflictError{ObjectID: appId, Attempts: attempt}
 			}
+			currentURLs = latestURLs
+			continue
 		}
+
+		if err := appRegistrations.PatchReplyURLs(appId, patchURLs); err != nil {
+			return SyncResult{Conflicts: conflicts}, err
+		}
+
+		result.Conflicts = conflicts
+		return result, nil
 	}
+}
 
-	if len(removedURLS) == 0 {
-		return nil, nil
+// getAppReplyURLs returns the redirect URIs of every platform of an application
+func getAppReplyURLs(appId string, graphClient *msgraphsdk.GraphServiceClient) (ReplyURLs, error) {
+	appObject, err := getApplication(appId, graphClient)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(newRedirectURLS) == 0 {
-		newRedirectURLS = []string{}
+	replyURLs := ReplyURLs{}
+	for _, platform := range v1beta1.Platforms {
+		replyURLs[platform] = platformReplyURLs(appObject, platform)
 	}
+	return replyURLs, nil
+}
 
-	if err := PatchAppReplyURLs(*syncSpec.ObjectID, newRedirectURLS, azureAppClient); err != nil {
-		return nil, err
+// changedPlatforms returns the platforms about to be patched whose redirect URIs are different in latestURLs
+func changedPlatforms(currentURLs ReplyURLs, latestURLs ReplyURLs, patchURLs ReplyURLs) (changed []v1beta1.Platform) {
+	for _, platform := range v1beta1.Platforms {
+		if _, patched := patchURLs[platform]; !patched {
+			continue
+		}
+
+		current, latest := currentURLs[platform], latestURLs[platform]
+		if len(current) == 0 && len(latest) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(current, latest) {
+			changed = append(changed, platform)
+		}
 	}
-	return removedURLS, nil
+	return changed
 }
 
-func ProcessHost(ingresses *v1.IngressList, syncSpec v1alpha1.ReplyURLSyncSpec, creds ClientSecretCredentials) (result ctrl.Result, err error) {
+/*
+diffReplyURLs works out the redirect URIs of the platforms of an app registration that change
+when the managed reply URLs are synced, missing managed reply URLs are added and the ones the
+operator owns that are no longer managed are removed from the platform they were added to.
+Reply URLs matching the reply URL filter that the operator doesn't own are left to the unmanaged
+URL policy. Only the platform of the sync and the platforms it has managed or owned reply URLs
+for are looked at, platforms without changes are left out.
+
+When no owned reply URLs have been recorded, e.g. on the first sync after upgrading from a
+version without them, the managed reply URLs already on the app registration that match the
+reply URL filter are taken as owned so they are still removed when their hosts go.
+*/
+func diffReplyURLs(currentURLs ReplyURLs, managedURLs ReplyURLs, ownedURLs ReplyURLs, syncPlatform v1beta1.Platform,
+	replyURLFilter string, unmanagedURLPolicy v1beta1.UnmanagedURLPolicy) (patchURLs ReplyURLs, result SyncResult, err error) {
+	if ownedURLs == nil {
+		if ownedURLs, err = seedOwnedURLs(currentURLs, managedURLs, replyURLFilter); err != nil {
+			return nil, SyncResult{}, err
+		}
+		result.SeededURLs = ownedURLs.All()
+	}
+
+	patchURLs = ReplyURLs{}
+	result.OwnedURLs = ReplyURLs{}
+
+	for _, platform := range v1beta1.Platforms {
+		if platform != syncPlatform && len(managedURLs[platform]) == 0 && len(ownedURLs[platform]) == 0 {
+			continue
+		}
 
-	var (
-		urls           []string
-		azureAppClient *msgraphsdk.GraphServiceClient
-		workerLog      = ctrl.Log
-	)
+		newRedirectURLs, platformRemovedURLs, platformUnmanagedURLs, err := removeUnmanagedURLs(
+			currentURLs[platform], managedURLs[platform], ownedURLs[platform], replyURLFilter, unmanagedURLPolicy)
+		if err != nil {
+			return nil, SyncResult{}, err
+		}
+		result.UnmanagedURLs = append(result.UnmanagedURLs, platformUnmanagedURLs...)
+
+		var platformAddedURLs, platformOwnedURLs []string
+		for _, url := range managedURLs[platform] {
+			if !swag.ContainsStrings(newRedirectURLs, url) {
+				newRedirectURLs = append(newRedirectURLs, url)
+				platformAddedURLs = append(platformAddedURLs, url)
+				platformOwnedURLs = append(platformOwnedURLs, url)
+			} else if swag.ContainsStrings(ownedURLs[platform], url) {
+				platformOwnedURLs = append(platformOwnedURLs, url)
+			}
+		}
 
-	if azureAppClient, err = CreateClient(&creds); err != nil {
-		return ctrl.Result{}, err
+		// Reply URLs added by hand that are also managed aren't owned, so they are kept when their hosts go
+		if platformOwnedURLs != nil {
+			result.OwnedURLs[platform] = platformOwnedURLs
+		}
+
+		if len(platformAddedURLs) == 0 && len(platformRemovedURLs) == 0 {
+			continue
+		}
+
+		patchURLs[platform] = newRedirectURLs
+		result.AddedURLs = append(result.AddedURLs, platformAddedURLs...)
+		result.RemovedURLs = append(result.RemovedURLs, platformRemovedURLs...)
 	}
 
-	formattedURLs, err := FilterAndFormatIngressHosts(ingresses, *syncSpec.DomainFilter, *syncSpec.IngressClassFilter)
+	return patchURLs, result, nil
+}
 
-	if err != nil {
-		workerLog.Error(err, "Unable to filter lists")
-	}
-
-	for _, url := range formattedURLs {
-
-		if urls, err = GetReplyURLs(*syncSpec.ObjectID, azureAppClient); err != nil {
-			return ctrl.Result{}, err
-		} else {
-			if !swag.ContainsStrings(urls, url) {
-				urls = append(urls, url)
-				if err := PatchAppReplyURLs(*syncSpec.ObjectID, urls, azureAppClient); err != nil {
-					return ctrl.Result{}, err
-				}
-				workerLog.Info("Reply URL added",
-					"URL", url,
-					"object id", *syncSpec.ObjectID, "ingressClassName", *syncSpec.IngressClassFilter)
+// seedOwnedURLs returns the managed reply URLs on each platform of an app registration that match the reply URL filter
+func seedOwnedURLs(currentURLs ReplyURLs, managedURLs ReplyURLs, replyURLFilter string) (ReplyURLs, error) {
+	ownedURLs := ReplyURLs{}
+
+	for platform, urls := range managedURLs {
+		for _, url := range urls {
+			if !swag.ContainsStrings(currentURLs[platform], url) {
+				continue
+			}
+
+			matched, err := matchesReplyURLFilter(replyURLFilter, url)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				ownedURLs[platform] = append(ownedURLs[platform], url)
 			}
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ownedURLs, nil
+}
+
+/*
+removeUnmanagedURLs splits urls into the reply URLs that should be kept and the ones that should
+be removed. Reply URLs owned by the operator that are no longer managed are always removed, the
+unmanaged URL policy decides what happens to the ones matching the filter that it doesn't own.
+*/
+func removeUnmanagedURLs(urls []string, managedURLs []string, ownedURLs []string, replyURLFilter string,
+	unmanagedURLPolicy v1beta1.UnmanagedURLPolicy) (newRedirectURLS []string, removedURLS []string, unmanagedURLs []string, err error) {
+	for _, url := range urls {
+		if swag.ContainsStrings(managedURLs, url) {
+			newRedirectURLS = append(newRedirectURLS, url)
+			continue
+		}
+
+		if swag.ContainsStrings(ownedURLs, url) {
+			removedURLS = append(removedURLS, url)
+			continue
+		}
+
+		matched, err := matchesReplyURLFilter(replyURLFilter, url)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		switch {
+		case matched && unmanagedURLPolicy == v1beta1.UnmanagedURLPolicyRemove:
+			removedURLS = append(removedURLS, url)
+		case matched && unmanagedURLPolicy == v1beta1.UnmanagedURLPolicyReport:
+			unmanagedURLs = append(unmanagedURLs, url)
+			newRedirectURLS = append(newRedirectURLS, url)
+		default:
+			newRedirectURLS = append(newRedirectURLS, url)
+		}
+	}
+
+	if len(newRedirectURLS) == 0 {
+		newRedirectURLS = []string{}
+	}
+
+	return newRedirectURLS, removedURLS, unmanagedURLs, nil
+}
+
+/*
+matchesReplyURLFilter reports whether a reply URL matches the reply URL filter. If a filter
+isn't set every reply URL matches, reply URLs not matching a filter that is set are always kept.
+*/
+func matchesReplyURLFilter(replyURLFilter string, url string) (bool, error) {
+	if replyURLFilter == "" {
+		return true, nil
+	}
+	return regexp.MatchString(replyURLFilter, url)
 }
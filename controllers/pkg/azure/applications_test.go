@@ -0,0 +1,344 @@
+package azureGraph
+
+import (
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"reflect"
+	"testing"
+)
+
+func TestDiffReplyURLs(t *testing.T) {
+	var (
+		app1URL     = "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app2URL     = "https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app3URL     = "https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		externalURL = "https://login.example.com/callback"
+	)
+
+	tests := []struct {
+		name               string
+		currentURLs        ReplyURLs
+		managedURLs        ReplyURLs
+		ownedURLs          ReplyURLs
+		syncPlatform       v1beta1.Platform
+		unmanagedURLPolicy v1beta1.UnmanagedURLPolicy
+		replyURLFilter     string
+		expectedPatchURLs  ReplyURLs
+		expectedResult     SyncResult
+	}{
+		{
+			name:              "nothing changed",
+			currentURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL, externalURL}},
+			managedURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			ownedURLs:         ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			replyURLFilter:    ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs: ReplyURLs{},
+			expectedResult:    SyncResult{OwnedURLs: ReplyURLs{v1beta1.PlatformWeb: {app1URL}}},
+		},
+		{
+			name:              "added and removed",
+			currentURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL, externalURL}},
+			managedURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL, app3URL}},
+			ownedURLs:         ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			replyURLFilter:    ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs: ReplyURLs{v1beta1.PlatformWeb: {app1URL, externalURL, app3URL}},
+			expectedResult: SyncResult{
+				AddedURLs:   []string{app3URL},
+				RemovedURLs: []string{app2URL},
+				OwnedURLs:   ReplyURLs{v1beta1.PlatformWeb: {app1URL, app3URL}},
+			},
+		},
+		{
+			name:              "every platform in one patch",
+			currentURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}, v1beta1.PlatformSPA: {externalURL}},
+			managedURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: {app3URL}},
+			ownedURLs:         ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			replyURLFilter:    ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs: ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: {externalURL, app3URL}},
+			expectedResult: SyncResult{
+				AddedURLs:   []string{app3URL},
+				RemovedURLs: []string{app2URL},
+				OwnedURLs:   ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: {app3URL}},
+			},
+		},
+		{
+			name:              "owned urls cleaned up without managed urls",
+			currentURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: {app2URL, app1URL}},
+			managedURLs:       ReplyURLs{},
+			ownedURLs:         ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: {app2URL}},
+			replyURLFilter:    ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs: ReplyURLs{v1beta1.PlatformWeb: {}, v1beta1.PlatformSPA: {app1URL}},
+			expectedResult:    SyncResult{RemovedURLs: []string{app1URL, app2URL}, OwnedURLs: ReplyURLs{}},
+		},
+		{
+			name:              "platform switched",
+			currentURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL, externalURL}},
+			managedURLs:       ReplyURLs{v1beta1.PlatformSPA: {app1URL}},
+			ownedURLs:         ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			syncPlatform:      v1beta1.PlatformSPA,
+			replyURLFilter:    ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs: ReplyURLs{v1beta1.PlatformWeb: {externalURL}, v1beta1.PlatformSPA: {app1URL}},
+			expectedResult: SyncResult{
+				AddedURLs:   []string{app1URL},
+				RemovedURLs: []string{app1URL},
+				OwnedURLs:   ReplyURLs{v1beta1.PlatformSPA: {app1URL}},
+			},
+		},
+		{
+			name:               "hand added url ignored",
+			currentURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			managedURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			ownedURLs:          ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			unmanagedURLPolicy: v1beta1.UnmanagedURLPolicyIgnore,
+			replyURLFilter:     ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs:  ReplyURLs{},
+			expectedResult:     SyncResult{OwnedURLs: ReplyURLs{v1beta1.PlatformWeb: {app1URL}}},
+		},
+		{
+			name:               "hand added url reported",
+			currentURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL, externalURL}},
+			managedURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL, app3URL}},
+			ownedURLs:          ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			unmanagedURLPolicy: v1beta1.UnmanagedURLPolicyReport,
+			replyURLFilter:     ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs:  ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL, externalURL, app3URL}},
+			expectedResult: SyncResult{
+				AddedURLs:     []string{app3URL},
+				UnmanagedURLs: []string{app2URL},
+				OwnedURLs:     ReplyURLs{v1beta1.PlatformWeb: {app1URL, app3URL}},
+			},
+		},
+		{
+			name:               "hand added url removed",
+			currentURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL, externalURL}},
+			managedURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			ownedURLs:          ReplyURLs{},
+			unmanagedURLPolicy: v1beta1.UnmanagedURLPolicyRemove,
+			replyURLFilter:     ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs:  ReplyURLs{v1beta1.PlatformWeb: {app1URL, externalURL}},
+			expectedResult:     SyncResult{RemovedURLs: []string{app2URL}, OwnedURLs: ReplyURLs{}},
+		},
+		{
+			name:              "hand added url also managed not owned",
+			currentURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			managedURLs:       ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			ownedURLs:         ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			replyURLFilter:    ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs: ReplyURLs{},
+			expectedResult:    SyncResult{OwnedURLs: ReplyURLs{v1beta1.PlatformWeb: {app1URL}}},
+		},
+		{
+			name:               "owned url removed outside the filter",
+			currentURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL, externalURL}},
+			managedURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			ownedURLs:          ReplyURLs{v1beta1.PlatformWeb: {app1URL, externalURL}},
+			unmanagedURLPolicy: v1beta1.UnmanagedURLPolicyIgnore,
+			replyURLFilter:     ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs:  ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			expectedResult: SyncResult{
+				RemovedURLs: []string{externalURL},
+				OwnedURLs:   ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			},
+		},
+		{
+			name:               "owned urls seeded without a ledger",
+			currentURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL, externalURL}},
+			managedURLs:        ReplyURLs{v1beta1.PlatformWeb: {app1URL, externalURL, app3URL}},
+			unmanagedURLPolicy: v1beta1.UnmanagedURLPolicyIgnore,
+			replyURLFilter:     ".*.sandbox.platform.hmcts.net",
+			expectedPatchURLs:  ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL, externalURL, app3URL}},
+			expectedResult: SyncResult{
+				AddedURLs:  []string{app3URL},
+				OwnedURLs:  ReplyURLs{v1beta1.PlatformWeb: {app1URL, app3URL}},
+				SeededURLs: []string{app1URL},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		syncPlatform := test.syncPlatform
+		if syncPlatform == "" {
+			syncPlatform = v1beta1.PlatformWeb
+		}
+
+		patchURLs, result, err := diffReplyURLs(test.currentURLs, test.managedURLs, test.ownedURLs,
+			syncPlatform, test.replyURLFilter, test.unmanagedURLPolicy)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		if !reflect.DeepEqual(patchURLs, test.expectedPatchURLs) || !reflect.DeepEqual(result, test.expectedResult) {
+			t.Errorf("Result %v %+v not equal to the expected result %v %+v\nTest: %s\n",
+				patchURLs, result, test.expectedPatchURLs, test.expectedResult, test.name)
+		}
+	}
+}
+
+func TestChangedPlatforms(t *testing.T) {
+	var (
+		app1URL = "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app2URL = "https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"
+	)
+
+	tests := []struct {
+		name            string
+		currentURLs     ReplyURLs
+		latestURLs      ReplyURLs
+		patchURLs       ReplyURLs
+		expectedChanged []v1beta1.Platform
+	}{
+		{
+			name:        "unchanged",
+			currentURLs: ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: nil},
+			latestURLs:  ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: {}},
+			patchURLs:   ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}, v1beta1.PlatformSPA: {app2URL}},
+		},
+		{
+			name:            "patched platform changed",
+			currentURLs:     ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			latestURLs:      ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			patchURLs:       ReplyURLs{v1beta1.PlatformWeb: {}},
+			expectedChanged: []v1beta1.Platform{v1beta1.PlatformWeb},
+		},
+		{
+			name:        "other platform changed",
+			currentURLs: ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			latestURLs:  ReplyURLs{v1beta1.PlatformWeb: {app1URL}, v1beta1.PlatformSPA: {app2URL}},
+			patchURLs:   ReplyURLs{v1beta1.PlatformWeb: {}},
+		},
+	}
+
+	for _, test := range tests {
+		changed := changedPlatforms(test.currentURLs, test.latestURLs, test.patchURLs)
+
+		if !reflect.DeepEqual(changed, test.expectedChanged) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", changed, test.expectedChanged, test.name)
+		}
+	}
+}
+
+func TestRemoveReplyURLs(t *testing.T) {
+	var (
+		app1URL     = "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app2URL     = "https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		externalURL = "https://login.example.com/callback"
+	)
+
+	currentURLs := ReplyURLs{
+		v1beta1.PlatformWeb: {app1URL, externalURL},
+		v1beta1.PlatformSPA: {app2URL},
+	}
+	replyURLs := ReplyURLs{
+		v1beta1.PlatformWeb:          {app1URL},
+		v1beta1.PlatformSPA:          {app2URL},
+		v1beta1.PlatformPublicClient: {app1URL},
+	}
+
+	expectedPatchURLs := ReplyURLs{
+		v1beta1.PlatformWeb: {externalURL},
+		v1beta1.PlatformSPA: {},
+	}
+	expectedRemovedURLs := []string{app1URL, app2URL}
+
+	patchURLs, removedURLs := removeReplyURLs(currentURLs, replyURLs)
+	if !reflect.DeepEqual(patchURLs, expectedPatchURLs) || !reflect.DeepEqual(removedURLs, expectedRemovedURLs) {
+		t.Errorf("Result %v %v not equal to the expected result %v %v\nTest: %s\n",
+			patchURLs, removedURLs, expectedPatchURLs, expectedRemovedURLs, t.Name())
+	}
+}
+
+// fakeAppRegistrations is an app registration whose redirect URIs can be changed by another writer when they are read
+type fakeAppRegistrations struct {
+	replyURLs ReplyURLs
+	gets      int
+	patches   []ReplyURLs
+	// otherWriter is called before the redirect URIs are returned by each read
+	otherWriter func(get int, replyURLs ReplyURLs)
+}
+
+func (apps *fakeAppRegistrations) GetReplyURLs(string) (ReplyURLs, error) {
+	apps.gets++
+	if apps.otherWriter != nil {
+		apps.otherWriter(apps.gets, apps.replyURLs)
+	}
+
+	replyURLs := ReplyURLs{}
+	for platform, urls := range apps.replyURLs {
+		replyURLs[platform] = append([]string{}, urls...)
+	}
+	return replyURLs, nil
+}
+
+func (apps *fakeAppRegistrations) PatchReplyURLs(_ string, replyURLs ReplyURLs) error {
+	apps.patches = append(apps.patches, replyURLs)
+	for platform, urls := range replyURLs {
+		apps.replyURLs[platform] = urls
+	}
+	return nil
+}
+
+func TestUpdateAppReplyURLs(t *testing.T) {
+	var (
+		app1URL     = "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app2URL     = "https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		externalURL = "https://login.example.com/callback"
+	)
+
+	addExternalURL := func(replyURLs ReplyURLs) {
+		replyURLs[v1beta1.PlatformWeb] = append(replyURLs[v1beta1.PlatformWeb], externalURL)
+	}
+
+	tests := []struct {
+		name              string
+		otherWriter       func(get int, replyURLs ReplyURLs)
+		expectedPatches   []ReplyURLs
+		expectedConflicts int
+		expectedErr       error
+	}{
+		{
+			name:            "no other writer",
+			expectedPatches: []ReplyURLs{{v1beta1.PlatformWeb: {app1URL, app2URL}}},
+		},
+		{
+			name: "changed before the patch then synced",
+			otherWriter: func(get int, replyURLs ReplyURLs) {
+				if get == 2 {
+					addExternalURL(replyURLs)
+				}
+			},
+			expectedPatches:   []ReplyURLs{{v1beta1.PlatformWeb: {app1URL, externalURL, app2URL}}},
+			expectedConflicts: 1,
+		},
+		{
+			name: "changed before every patch",
+			otherWriter: func(get int, replyURLs ReplyURLs) {
+				if get > 1 {
+					addExternalURL(replyURLs)
+				}
+			},
+			expectedConflicts: maxSyncAttempts,
+			expectedErr:       ConflictError{ObjectID: "test-object-id", Attempts: maxSyncAttempts},
+		},
+	}
+
+	for _, test := range tests {
+		appRegistrations := &fakeAppRegistrations{
+			replyURLs:   ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			otherWriter: test.otherWriter,
+		}
+
+		result, err := updateAppReplyURLs("test-object-id", appRegistrations, func(currentURLs ReplyURLs) (ReplyURLs, SyncResult, error) {
+			return diffReplyURLs(currentURLs, ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}}, nil,
+				v1beta1.PlatformWeb, ".*.sandbox.platform.hmcts.net", v1beta1.UnmanagedURLPolicyIgnore)
+		})
+
+		if err != test.expectedErr {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", err, test.expectedErr, test.name)
+		}
+
+		if !reflect.DeepEqual(appRegistrations.patches, test.expectedPatches) || result.Conflicts != test.expectedConflicts {
+			t.Errorf("Result %v %d not equal to the expected result %v %d\nTest: %s\n",
+				appRegistrations.patches, result.Conflicts, test.expectedPatches, test.expectedConflicts, test.name)
+		}
+	}
+}
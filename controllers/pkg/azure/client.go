@@ -37,3 +37,33 @@ func CreateClient(c *ClientSecretCredentials) (client *msgraphsdk.GraphServiceCl
 	graphClient := msgraphsdk.NewGraphServiceClient(adapter)
 	return graphClient, nil
 }
+
+// AppRegistrations reads and writes the redirect URIs of app registrations
+type AppRegistrations interface {
+	// GetReplyURLs returns the redirect URIs of every platform of an app registration
+	GetReplyURLs(objectID string) (ReplyURLs, error)
+	// PatchReplyURLs sets the redirect URIs of an app registration on every platform in replyURLs with a single request
+	PatchReplyURLs(objectID string, replyURLs ReplyURLs) error
+}
+
+// graphAppRegistrations are the app registrations read and written with Microsoft Graph
+type graphAppRegistrations struct {
+	graphClient *msgraphsdk.GraphServiceClient
+}
+
+// NewAppRegistrations returns the app registrations that can be reached with a set of credentials
+func NewAppRegistrations(creds ClientSecretCredentials) (AppRegistrations, error) {
+	graphClient, err := CreateClient(&creds)
+	if err != nil {
+		return nil, err
+	}
+	return graphAppRegistrations{graphClient: graphClient}, nil
+}
+
+func (apps graphAppRegistrations) GetReplyURLs(objectID string) (ReplyURLs, error) {
+	return getAppReplyURLs(objectID, apps.graphClient)
+}
+
+func (apps graphAppRegistrations) PatchReplyURLs(objectID string, replyURLs ReplyURLs) error {
+	return PatchReplyURLs(objectID, replyURLs, apps.graphClient)
+}
@@ -18,3 +18,16 @@ func (err *FieldNotFoundError) SetField(field string) {
 func (err *FieldNotFoundError) SetResource(resource string) {
 	err.Resource = resource
 }
+
+/*
+ConflictError is returned when the redirect URIs of an app registration kept being changed by
+someone else, such as another operator or a person in the portal, between reading and writing them
+*/
+type ConflictError struct {
+	ObjectID string
+	Attempts int
+}
+
+func (err ConflictError) Error() string {
+	return fmt.Sprintf("the reply URLs of app registration %s were changed by another writer during each of %d attempts to sync them", err.ObjectID, err.Attempts)
+}
@@ -1,10 +1,35 @@
 package azureGraph
 
-import "github.com/hmcts/reply-urls-operator/api/v1alpha1"
+import "github.com/hmcts/reply-urls-operator/api/v1beta1"
 
 type PatchOptions struct {
-	IngressHosts []string
-	Syncer       v1alpha1.ReplyURLSync
+	ReplyURLs ReplyURLs
+	Syncer    v1beta1.ReplyURLSync
+}
+
+// SyncResult holds the changes made to the reply URLs of an app registration by a sync
+type SyncResult struct {
+	AddedURLs   []string
+	RemovedURLs []string
+	// UnmanagedURLs are the reply URLs matching the reply URL filter that the operator didn't add, only set with the Report policy
+	UnmanagedURLs []string
+	// Conflicts is the number of times the app registration was changed by another writer while syncing
+	Conflicts int
+	// OwnedURLs are the reply URLs owned by the operator after the sync, the owned ones still managed and the ones added
+	OwnedURLs ReplyURLs
+	// SeededURLs are the managed reply URLs already on the app registration that became owned as none were recorded
+	SeededURLs []string
+}
+
+// ReplyURLs are reply URLs grouped by the platform they are synced to
+type ReplyURLs map[v1beta1.Platform][]string
+
+// All returns the reply URLs of every platform
+func (replyURLs ReplyURLs) All() (urls []string) {
+	for _, platform := range v1beta1.Platforms {
+		urls = append(urls, replyURLs[platform]...)
+	}
+	return urls
 }
 
 type ClientSecretCredentials struct {
@@ -1,36 +1,375 @@
 package azureGraph
 
 import (
-	"fmt"
+	"bytes"
+	"github.com/go-openapi/swag"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
 	v1 "k8s.io/api/networking/v1"
+	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
 )
 
-func FilterAndFormatIngressHosts(ingressList *v1.IngressList, domainFilter string, ingressClassFilter string) (ingressHosts []string, err error) {
-	for _, ingress := range ingressList.Items {
+/*
+IngressRuleHosts returns the hosts of the rules of an ingress, and the hosts only found in
+its TLS config. Hosts covered by the TLS config have the https scheme, the others http.
+*/
+func IngressRuleHosts(ingress v1.Ingress) (hosts []v1beta1.Host) {
+	var (
+		tlsHosts  []string
+		ruleHosts []string
+	)
+	for _, tls := range ingress.Spec.TLS {
+		tlsHosts = append(tlsHosts, tls.Hosts...)
+	}
+
+	ingressHost := func(host string, path string) v1beta1.Host {
+		scheme := "http"
+		if coveredByTLS(host, tlsHosts) {
+			scheme = "https"
+		}
 
-		/*
-		  If either ingressClass name annotation and ingressClassName spec field don't
-		  match the ingressClassNameFilter continue and filter
-		*/
+		return v1beta1.Host{
+			Host:        host,
+			Scheme:      scheme,
+			Path:        path,
+			Kind:        "Ingress",
+			Namespace:   ingress.Namespace,
+			Name:        ingress.Name,
+			Labels:      ingress.Labels,
+			Annotations: ingress.Annotations,
+		}
+	}
 
-		if (ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName != ingressClassFilter) &&
-			ingress.Annotations["kubernetes.io/ingress.class"] != ingressClassFilter {
+	for _, rule := range ingress.Spec.Rules {
+		host := ingressHost(rule.Host, rulePath(rule))
+		host.Paths = rulePaths(rule)
+		hosts = append(hosts, host)
+		ruleHosts = append(ruleHosts, rule.Host)
+	}
 
+	// Hosts with a certificate but no rule are served by the default backend
+	for _, tlsHost := range tlsHosts {
+		if strings.HasPrefix(tlsHost, "*") || swag.ContainsStringsCI(ruleHosts, tlsHost) {
 			continue
+		}
+		hosts = append(hosts, ingressHost(tlsHost, ""))
+		ruleHosts = append(ruleHosts, tlsHost)
+	}
+	return hosts
+}
 
+// coveredByTLS reports whether a host is one of tlsHosts or matches one of their wildcards
+func coveredByTLS(host string, tlsHosts []string) bool {
+	for _, tlsHost := range tlsHosts {
+		if strings.EqualFold(tlsHost, host) {
+			return true
 		}
-		for _, rule := range ingress.Spec.Rules {
-			if isMatch, err := regexp.MatchString(domainFilter, rule.Host); err != nil {
-				return nil, err
-			} else if !isMatch {
-				continue
+
+		// Wildcards only match a single label
+		if wildcardDomain := strings.TrimPrefix(tlsHost, "*"); wildcardDomain != tlsHost {
+			if label, domain, found := strings.Cut(host, "."); found && label != "" && strings.EqualFold("."+domain, wildcardDomain) {
+				return true
 			}
+		}
+	}
+	return false
+}
+
+/*
+FilterAndFormatHosts returns the reply URLs of the hosts synced by syncer grouped by their
+platform. Hosts are filtered by the domain filter of the sync and the annotations of the
+resource they came from, which can also change their callback paths and platform. The static
+URLs of the sync are always added to its platform. Reply URLs Azure wouldn't accept are
+returned as invalidURLs rather than with the reply URLs.
+*/
+func FilterAndFormatHosts(hosts []v1beta1.Host, syncer v1beta1.ReplyURLSync) (replyURLs ReplyURLs, invalidURLs []string, err error) {
+	if hosts, err = SyncedHosts(hosts, syncer); err != nil {
+		return nil, nil, err
+	}
 
-			// If ingress host matches domain regex add it to the list of ingresses that should be managed
-			ingressHosts = append(ingressHosts, fmt.Sprintf("https://%s/oauth-proxy/callback", rule.Host))
+	if replyURLs, invalidURLs, err = FormatHosts(hosts, syncer); err != nil {
+		return nil, nil, err
+	}
+
+	return replyURLs, append(invalidURLs, AddStaticURLs(replyURLs, syncer)...), nil
+}
+
+/*
+SyncedHosts returns the hosts synced by syncer, the ones matching its domain filter from
+resources that haven't opted out or asked for a different ReplyURLSync
+*/
+func SyncedHosts(hosts []v1beta1.Host, syncer v1beta1.ReplyURLSync) (syncedHosts []v1beta1.Host, err error) {
+	for _, host := range hosts {
+		if host.Host == "" {
+			continue
+		}
+
+		// Skip resources that have opted out or asked for a different ReplyURLSync
+		if !SyncedBy(host.Annotations, syncer) {
+			continue
+		}
+
+		if isMatch, err := regexp.MatchString(syncer.Spec.Filters.DomainFilter, host.Host); err != nil {
+			return nil, err
+		} else if !isMatch {
+			continue
+		}
+
+		syncedHosts = append(syncedHosts, host)
+	}
+	return syncedHosts, nil
+}
+
+/*
+FormatHosts returns the reply URLs generated for hosts from the URL templates of syncer,
+or the callback paths of the resource they came from, grouped by their platform. Reply
+URLs Azure wouldn't accept are returned as invalidURLs rather than with the reply URLs.
+*/
+func FormatHosts(hosts []v1beta1.Host, syncer v1beta1.ReplyURLSync) (replyURLs ReplyURLs, invalidURLs []string, err error) {
+	syncSpec := syncer.Spec
+
+	replyURLs = ReplyURLs{}
+
+	replyURLTemplates, err := parseURLTemplates(syncSpec.Target.Templates())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, host := range hosts {
+		// The scheme worked out from the TLS config of a resource is only used when the sync asks for it
+		if host.Scheme == "" || !syncSpec.Target.SchemeFromTLS {
+			host.Scheme = "https"
+		}
+
+		platform := AnnotatedPlatform(host.Annotations, syncSpec.Target.Platform)
+
+		hostTemplates := replyURLTemplates
+		if callbackPaths := CallbackPaths(host.Annotations); callbackPaths != nil {
+			if hostTemplates, err = parseCallbackPathTemplates(callbackPaths); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Path aware syncs generate reply URLs for every path the host is served on
+		hostPaths := []string{host.Path}
+		if syncSpec.Target.PathAware && host.Paths != nil {
+			hostPaths = host.Paths
+		}
+
+		for _, hostPath := range hostPaths {
+			host.Path = hostPath
+
+			for _, replyURLTemplate := range hostTemplates {
+				replyURL, err := RenderReplyURL(replyURLTemplate, host)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				if syncSpec.Target.PathAware {
+					if replyURL, err = prefixPath(replyURL, hostPath); err != nil {
+						return nil, nil, err
+					}
+				}
+
+				if !v1beta1.ValidReplyURL(replyURL, syncSpec.Target.AllowHTTP) {
+					if !swag.ContainsStrings(invalidURLs, replyURL) {
+						invalidURLs = append(invalidURLs, replyURL)
+					}
+					continue
+				}
+
+				if !swag.ContainsStrings(replyURLs[platform], replyURL) {
+					replyURLs[platform] = append(replyURLs[platform], replyURL)
+				}
+			}
+		}
+	}
+	return replyURLs, invalidURLs, nil
+}
+
+/*
+AddStaticURLs adds the static URLs of syncer to its platform in replyURLs, returning the ones
+Azure wouldn't accept. They have been set explicitly so http localhost URLs don't need allowHTTP.
+*/
+func AddStaticURLs(replyURLs ReplyURLs, syncer v1beta1.ReplyURLSync) (invalidURLs []string) {
+	platform := AnnotatedPlatform(nil, syncer.Spec.Target.Platform)
+	for _, staticURL := range syncer.Spec.Target.StaticURLs {
+		if !v1beta1.ValidReplyURL(staticURL, true) {
+			if !swag.ContainsStrings(invalidURLs, staticURL) {
+				invalidURLs = append(invalidURLs, staticURL)
+			}
+			continue
+		}
+
+		if !swag.ContainsStrings(replyURLs[platform], staticURL) {
+			replyURLs[platform] = append(replyURLs[platform], staticURL)
+		}
+	}
+	return invalidURLs
+}
+
+/*
+prefixPath puts the path a host is served on in front of the path of a reply URL, unless
+the reply URL is already under it because its template uses .Path
+*/
+func prefixPath(replyURL string, hostPath string) (string, error) {
+	if hostPath == "" {
+		return replyURL, nil
+	}
+
+	parsedURL, err := url.Parse(replyURL)
+	if err != nil {
+		return "", err
+	}
+
+	if parsedURL.Path == hostPath || strings.HasPrefix(parsedURL.Path, hostPath+"/") {
+		return replyURL, nil
+	}
+
+	parsedURL.Path = hostPath + parsedURL.Path
+	parsedURL.RawPath = ""
+	return parsedURL.String(), nil
+}
+
+/*
+SyncedBy reports whether the annotations of a resource allow its hosts to be synced by
+syncer. Resources can opt out of being synced, or name the only ReplyURLSync that should
+sync them either by name or by namespace/name.
+*/
+func SyncedBy(annotations map[string]string, syncer v1beta1.ReplyURLSync) bool {
+	if strings.EqualFold(annotations[v1beta1.IgnoreAnnotation], "true") {
+		return false
+	}
+
+	syncName, found := annotations[v1beta1.ReplyURLSyncAnnotation]
+	if !found || syncName == "" {
+		return true
+	}
+
+	if namespace, name, isNamespaced := strings.Cut(syncName, "/"); isNamespaced {
+		return namespace == syncer.Namespace && name == syncer.Name
+	}
+	return syncName == syncer.Name
+}
+
+// CallbackPaths returns the callback paths set by the callback paths annotation, or nil if it isn't set
+func CallbackPaths(annotations map[string]string) (callbackPaths []string) {
+	for _, callbackPath := range strings.Split(annotations[v1beta1.CallbackPathsAnnotation], ",") {
+		if callbackPath = strings.TrimSpace(callbackPath); callbackPath != "" {
+			callbackPaths = append(callbackPaths, "/"+strings.TrimPrefix(callbackPath, "/"))
+		}
+	}
+	return callbackPaths
+}
+
+/*
+AnnotatedPlatform returns the platform the reply URLs of a resource are synced to, which is
+the platform set by its annotation or syncPlatform. Unknown platforms are ignored so a typo
+in one resource doesn't stop the others being synced.
+*/
+func AnnotatedPlatform(annotations map[string]string, syncPlatform v1beta1.Platform) v1beta1.Platform {
+	if syncPlatform == "" {
+		syncPlatform = v1beta1.PlatformWeb
+	}
+
+	for _, platform := range v1beta1.Platforms {
+		if strings.EqualFold(annotations[v1beta1.PlatformAnnotation], string(platform)) {
+			return platform
+		}
+	}
+	return syncPlatform
+}
+
+/*
+ParseURLTemplate parses a reply URL template, an empty template is parsed as the
+default template. Missing labels and annotations are rendered as empty strings.
+*/
+func ParseURLTemplate(urlTemplate string) (*template.Template, error) {
+	if urlTemplate == "" {
+		urlTemplate = v1beta1.DefaultURLTemplate
+	}
+
+	return template.New("urlTemplate").Option("missingkey=zero").Parse(urlTemplate)
+}
+
+func parseURLTemplates(urlTemplates []string) (replyURLTemplates []*template.Template, err error) {
+	for _, urlTemplate := range urlTemplates {
+		replyURLTemplate, err := ParseURLTemplate(urlTemplate)
+		if err != nil {
+			return nil, err
+		}
+		replyURLTemplates = append(replyURLTemplates, replyURLTemplate)
+	}
+	return replyURLTemplates, nil
+}
+
+// parseCallbackPathTemplates returns templates for the reply URLs of a host with each of the callback paths
+func parseCallbackPathTemplates(callbackPaths []string) ([]*template.Template, error) {
+	urlTemplates := make([]string, 0, len(callbackPaths))
+	for _, callbackPath := range callbackPaths {
+		// Paths are used as they are rather than as templates
+		urlTemplates = append(urlTemplates, "{{ .Scheme }}://{{ .Host }}{{ "+strconv.Quote(callbackPath)+" }}")
+	}
+	return parseURLTemplates(urlTemplates)
+}
+
+// RenderReplyURL renders the reply URL for a host with a parsed URL template
+func RenderReplyURL(urlTemplate *template.Template, host v1beta1.Host) (string, error) {
+	var replyURL bytes.Buffer
+
+	if err := urlTemplate.Execute(&replyURL, host); err != nil {
+		return "", err
+	}
+
+	return replyURL.String(), nil
+}
+
+// rulePath returns the first path of an ingress rule without a trailing slash so it can be joined to other paths
+func rulePath(rule v1.IngressRule) string {
+	if rule.HTTP == nil || len(rule.HTTP.Paths) == 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(rule.HTTP.Paths[0].Path, "/")
+}
+
+/*
+rulePaths returns the unique paths an ingress rule serves its host under, without trailing
+slashes. Exact paths are skipped as nothing under them is served, and implementation specific
+paths, which are regular expressions for some ingress controllers, are cut at the first
+character that isn't part of a literal prefix. Rules without paths serve every path under /.
+*/
+func rulePaths(rule v1.IngressRule) (paths []string) {
+	if rule.HTTP == nil || len(rule.HTTP.Paths) == 0 {
+		return []string{""}
+	}
+
+	// A rule with only exact paths gives no paths rather than falling back to Path
+	paths = []string{}
+
+	for _, httpPath := range rule.HTTP.Paths {
+		path := httpPath.Path
+		pathType := v1.PathTypeImplementationSpecific
+		if httpPath.PathType != nil {
+			pathType = *httpPath.PathType
+		}
+
+		switch pathType {
+		case v1.PathTypeExact:
+			continue
+		case v1.PathTypeImplementationSpecific:
+			if i := strings.IndexAny(path, "([{*?+|$^\\"); i >= 0 {
+				path = strings.TrimSuffix(path[:i], ".")
+			}
+		}
 
+		path = strings.TrimSuffix(path, "/")
+		if !swag.ContainsStrings(paths, path) {
+			paths = append(paths, path)
 		}
 	}
-	return ingressHosts, nil
+	return paths
 }
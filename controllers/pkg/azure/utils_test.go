@@ -1,6 +1,7 @@
 package azureGraph
 
 import (
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
 	v1 "k8s.io/api/networking/v1"
 	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
@@ -8,120 +9,513 @@ import (
 	"testing"
 )
 
-func TestFilterAndFormatIngressHosts(t *testing.T) {
-	var (
-		domainFilter           = ".*.sandbox.platform.hmcts.net"
-		ingressClassNameFilter = "traefik"
-	)
+func newTestSyncer(domainFilter string, urlTemplates ...string) v1beta1.ReplyURLSync {
+	syncer := v1beta1.ReplyURLSync{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      "test-reply-url-sync",
+			Namespace: "admin",
+		},
+		Spec: v1beta1.ReplyURLSyncSpec{
+			Filters: v1beta1.FiltersSpec{
+				DomainFilter: domainFilter,
+			},
+		},
+	}
 
-	noIngressClassAnnotation := v1meta.ObjectMeta{
-		Annotations: map[string]string{},
+	if len(urlTemplates) > 0 {
+		syncer.Spec.Target.URLTemplate = urlTemplates[0]
+		syncer.Spec.Target.URLTemplates = urlTemplates[1:]
 	}
 
-	withIngressClassAnnotation := v1meta.ObjectMeta{
-		Annotations: map[string]string{
-			"kubernetes.io/ingress.class": "traefik",
-		},
+	return syncer
+}
+
+// ingressListHosts returns the hosts of every ingress, the ingresses synced are selected by the controller
+func ingressListHosts(ingressList *v1.IngressList) (hosts []v1beta1.Host) {
+	for _, ingress := range ingressList.Items {
+		hosts = append(hosts, IngressRuleHosts(ingress)...)
+	}
+	return hosts
+}
+
+// formatIngressHosts returns the reply URLs of every platform for the hosts of the ingresses
+func formatIngressHosts(ingressList *v1.IngressList, syncer v1beta1.ReplyURLSync) ([]string, error) {
+	replyURLs, _, err := FilterAndFormatHosts(ingressListHosts(ingressList), syncer)
+	if err != nil {
+		return nil, err
+	}
+	return replyURLs.All(), nil
+}
+
+func TestFilterAndFormatHosts(t *testing.T) {
+	hosts := []v1beta1.Host{
+		{Host: "test-app-1.sandbox.platform.hmcts.net", Kind: "Ingress"},
+		{Host: "test-app-2.platform.hmcts.net", Kind: "Ingress"},
+		{Host: "test-app-3.staging.platform.hmcts.net", Kind: "Ingress"},
+		{Host: "", Kind: "Ingress"},
+		{Host: "test-app-4.sandbox.platform.hmcts.net", Kind: "HTTPRoute"},
 	}
 
-	wrongIngressClassAnnotation := v1meta.ObjectMeta{
-		Annotations: map[string]string{
-			"kubernetes.io/ingress.class": "wrong-traefik",
-		},
+	expectedList := []string{
+		"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+		"https://test-app-4.sandbox.platform.hmcts.net/oauth-proxy/callback",
+	}
+
+	replyURLs, _, err := FilterAndFormatHosts(hosts, newTestSyncer(".*.sandbox.platform.hmcts.net", v1beta1.HTTPSURLTemplate))
+	if err != nil {
+		t.Errorf("Unexpected error %v\nTest: %s\n", err, strings.ToLower(t.Name()))
+	} else if list := replyURLs.All(); !reflect.DeepEqual(list, expectedList) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n",
+			list, expectedList, strings.ToLower(t.Name()))
 	}
+}
+
+func TestFilterAndFormatHostsWithURLTemplate(t *testing.T) {
+	ingressClassNameFilter := "traefik"
 
 	ingressList := v1.IngressList{
 		Items: []v1.Ingress{
 			{
-				ObjectMeta: noIngressClassAnnotation,
-				Spec: v1.IngressSpec{
-					Rules: []v1.IngressRule{
-						{
-							Host: "test-app-1.sandbox.platform.hmcts.net",
-						},
+				ObjectMeta: v1meta.ObjectMeta{
+					Name:      "test-app-1",
+					Namespace: "test-namespace",
+					Labels: map[string]string{
+						"app.kubernetes.io/name": "test-app",
 					},
 				},
-			},
-			{
-				ObjectMeta: noIngressClassAnnotation,
 				Spec: v1.IngressSpec{
 					IngressClassName: &ingressClassNameFilter,
 					Rules: []v1.IngressRule{
 						{
-							Host: "test-app-2.platform.hmcts.net",
+							Host: "test-app-1.sandbox.platform.hmcts.net",
+							IngressRuleValue: v1.IngressRuleValue{
+								HTTP: &v1.HTTPIngressRuleValue{
+									Paths: []v1.HTTPIngressPath{
+										{Path: "/app/"},
+									},
+								},
+							},
 						},
-					},
-				},
-			},
-			{
-				ObjectMeta: withIngressClassAnnotation,
-				Spec: v1.IngressSpec{
-					Rules: []v1.IngressRule{
 						{
-							Host: "test-app-3.sandbox.platform.hmcts.net",
+							Host: "test-app-2.sandbox.platform.hmcts.net",
 						},
 					},
 				},
 			},
-			{
-				ObjectMeta: noIngressClassAnnotation,
-				Spec: v1.IngressSpec{
-					IngressClassName: &ingressClassNameFilter,
-					Rules: []v1.IngressRule{
-						{
-							Host: "test-app-4.sandbox.platform.hmcts.net",
-						},
-					},
-				},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		urlTemplates []string
+		expectedList []string
+	}{
+		{
+			name:         "path",
+			urlTemplates: []string{"https://{{ .Host }}{{ .Path }}/signin-oidc"},
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/app/signin-oidc",
+				"https://test-app-2.sandbox.platform.hmcts.net/signin-oidc",
 			},
-			{
-				ObjectMeta: withIngressClassAnnotation,
-				Spec: v1.IngressSpec{
+		},
+		{
+			name: "ingress metadata",
+			urlTemplates: []string{
+				`https://{{ .Host }}/{{ .Namespace }}/{{ .Name }}/{{ index .Labels "app.kubernetes.io/name" }}{{ index .Annotations "missing" }}`,
+			},
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/test-namespace/test-app-1/test-app",
+				"https://test-app-2.sandbox.platform.hmcts.net/test-namespace/test-app-1/test-app",
+			},
+		},
+		{
+			name: "multiple templates",
+			urlTemplates: []string{
+				"https://{{ .Host }}/signin-oidc",
+				"https://{{ .Host }}/silent-renew",
+				"https://{{ .Host }}/signin-oidc",
+			},
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/signin-oidc",
+				"https://test-app-1.sandbox.platform.hmcts.net/silent-renew",
+				"https://test-app-2.sandbox.platform.hmcts.net/signin-oidc",
+				"https://test-app-2.sandbox.platform.hmcts.net/silent-renew",
+			},
+		},
+	}
 
-					Rules: []v1.IngressRule{
-						{
-							Host: "test-app-5.staging.platform.hmcts.net",
-						},
-					},
-				},
+	for _, test := range tests {
+		list, err := formatIngressHosts(&ingressList, newTestSyncer(".*", test.urlTemplates...))
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+		} else if !reflect.DeepEqual(list, test.expectedList) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n",
+				list, test.expectedList, test.name)
+		}
+	}
+}
+
+func TestFilterAndFormatHostsWithAnnotations(t *testing.T) {
+	ingressClassNameFilter := "traefik"
+
+	newIngress := func(name string, annotations map[string]string) v1.Ingress {
+		return v1.Ingress{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name:        name,
+				Namespace:   "test-namespace",
+				Annotations: annotations,
 			},
-			{
-				ObjectMeta: withIngressClassAnnotation,
-				Spec: v1.IngressSpec{
-					IngressClassName: nil,
-					Rules: []v1.IngressRule{
-						{
-							Host: "test-app-6.sandbox.platform.hmcts.net",
-						},
+			Spec: v1.IngressSpec{
+				IngressClassName: &ingressClassNameFilter,
+				Rules: []v1.IngressRule{
+					{
+						Host: name + ".sandbox.platform.hmcts.net",
 					},
 				},
-			},
-			{
-				ObjectMeta: wrongIngressClassAnnotation,
-				Spec: v1.IngressSpec{
-					IngressClassName: nil,
-					Rules: []v1.IngressRule{
-						{
-							Host: "test-app-7.sandbox.platform.hmcts.net",
-						},
+				TLS: []v1.IngressTLS{
+					{
+						Hosts: []string{"*.sandbox.platform.hmcts.net"},
 					},
 				},
 			},
+		}
+	}
+
+	ingressList := v1.IngressList{
+		Items: []v1.Ingress{
+			newIngress("test-app-1", nil),
+			newIngress("test-app-2", map[string]string{
+				v1beta1.IgnoreAnnotation: "true",
+			}),
+			newIngress("test-app-3", map[string]string{
+				v1beta1.CallbackPathsAnnotation: "/signin-oidc, login/oauth2/code/azure",
+			}),
+			newIngress("test-app-4", map[string]string{
+				v1beta1.ReplyURLSyncAnnotation: "test-reply-url-sync",
+			}),
+			newIngress("test-app-5", map[string]string{
+				v1beta1.ReplyURLSyncAnnotation: "admin/test-reply-url-sync",
+			}),
+			newIngress("test-app-6", map[string]string{
+				v1beta1.ReplyURLSyncAnnotation: "other-reply-url-sync",
+			}),
+			newIngress("test-app-7", map[string]string{
+				v1beta1.ReplyURLSyncAnnotation: "default/test-reply-url-sync",
+			}),
 		},
 	}
 
 	expectedList := []string{
-		"https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback",
+		"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+		"https://test-app-3.sandbox.platform.hmcts.net/signin-oidc",
+		"https://test-app-3.sandbox.platform.hmcts.net/login/oauth2/code/azure",
 		"https://test-app-4.sandbox.platform.hmcts.net/oauth-proxy/callback",
-		"https://test-app-6.sandbox.platform.hmcts.net/oauth-proxy/callback",
+		"https://test-app-5.sandbox.platform.hmcts.net/oauth-proxy/callback",
 	}
 
-	if list, _ := FilterAndFormatIngressHosts(
+	if list, err := formatIngressHosts(
 		&ingressList,
-		domainFilter,
-		ingressClassNameFilter,
-	); !reflect.DeepEqual(list, expectedList) {
+		newTestSyncer(".*"),
+	); err != nil {
+		t.Errorf("Unexpected error %v\nTest: %s\n", err, strings.ToLower(t.Name()))
+	} else if !reflect.DeepEqual(list, expectedList) {
 		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n",
 			list, expectedList, strings.ToLower(t.Name()))
 	}
 }
+
+func TestFilterAndFormatHostsByPlatform(t *testing.T) {
+	ingressClassNameFilter := "traefik"
+
+	newIngress := func(name string, platform string) v1.Ingress {
+		ingress := v1.Ingress{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1.IngressSpec{
+				IngressClassName: &ingressClassNameFilter,
+				Rules: []v1.IngressRule{
+					{
+						Host: name + ".sandbox.platform.hmcts.net",
+					},
+				},
+			},
+		}
+		if platform != "" {
+			ingress.Annotations = map[string]string{v1beta1.PlatformAnnotation: platform}
+		}
+		return ingress
+	}
+
+	ingressList := v1.IngressList{
+		Items: []v1.Ingress{
+			newIngress("test-app-1", ""),
+			newIngress("test-app-2", "spa"),
+			newIngress("test-app-3", "PublicClient"),
+			newIngress("test-app-4", "not-a-platform"),
+		},
+	}
+
+	syncer := newTestSyncer(".*", "https://{{ .Host }}/")
+	syncer.Spec.Target.Platform = v1beta1.PlatformSPA
+
+	expectedURLs := ReplyURLs{
+		v1beta1.PlatformSPA: {
+			"https://test-app-1.sandbox.platform.hmcts.net/",
+			"https://test-app-2.sandbox.platform.hmcts.net/",
+			"https://test-app-4.sandbox.platform.hmcts.net/",
+		},
+		v1beta1.PlatformPublicClient: {
+			"https://test-app-3.sandbox.platform.hmcts.net/",
+		},
+	}
+
+	if replyURLs, _, err := FilterAndFormatHosts(ingressListHosts(&ingressList), syncer); err != nil {
+		t.Errorf("Unexpected error %v\nTest: %s\n", err, strings.ToLower(t.Name()))
+	} else if !reflect.DeepEqual(replyURLs, expectedURLs) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n",
+			replyURLs, expectedURLs, strings.ToLower(t.Name()))
+	}
+}
+
+func TestFilterAndFormatHostsWithTLS(t *testing.T) {
+	ingressClassNameFilter := "traefik"
+
+	newIngress := func(name string, ruleHosts []string, tlsHosts []string) v1.Ingress {
+		ingress := v1.Ingress{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name:      name,
+				Namespace: "test-namespace",
+			},
+			Spec: v1.IngressSpec{
+				IngressClassName: &ingressClassNameFilter,
+				TLS: []v1.IngressTLS{
+					{
+						Hosts: tlsHosts,
+					},
+				},
+			},
+		}
+
+		for _, host := range ruleHosts {
+			ingress.Spec.Rules = append(ingress.Spec.Rules, v1.IngressRule{Host: host})
+		}
+		return ingress
+	}
+
+	ingressList := v1.IngressList{
+		Items: []v1.Ingress{
+			newIngress("test-app-1",
+				[]string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+				[]string{"test-app-1.sandbox.platform.hmcts.net", "test-app-3.sandbox.platform.hmcts.net"},
+			),
+			newIngress("test-app-4",
+				[]string{"test-app-4.sandbox.platform.hmcts.net", "test-app-5.staging.sandbox.platform.hmcts.net"},
+				[]string{"*.sandbox.platform.hmcts.net"},
+			),
+			newIngress("localhost", []string{"localhost"}, nil),
+		},
+	}
+
+	tests := []struct {
+		name                string
+		schemeFromTLS       bool
+		allowHTTP           bool
+		expectedList        []string
+		expectedInvalidURLs []string
+	}{
+		{
+			name: "https for every host",
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-4.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-5.staging.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://localhost/oauth-proxy/callback",
+			},
+		},
+		{
+			name:          "https only",
+			schemeFromTLS: true,
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-4.sandbox.platform.hmcts.net/oauth-proxy/callback",
+			},
+			expectedInvalidURLs: []string{
+				"http://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"http://test-app-5.staging.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"http://localhost/oauth-proxy/callback",
+			},
+		},
+		{
+			name:          "http allowed",
+			schemeFromTLS: true,
+			allowHTTP:     true,
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-4.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"http://localhost/oauth-proxy/callback",
+			},
+			expectedInvalidURLs: []string{
+				"http://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"http://test-app-5.staging.sandbox.platform.hmcts.net/oauth-proxy/callback",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := newTestSyncer(".*")
+		syncer.Spec.Target.SchemeFromTLS = test.schemeFromTLS
+		syncer.Spec.Target.AllowHTTP = test.allowHTTP
+
+		replyURLs, invalidURLs, err := FilterAndFormatHosts(ingressListHosts(&ingressList), syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		if list := replyURLs.All(); !reflect.DeepEqual(list, test.expectedList) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", list, test.expectedList, test.name)
+		}
+
+		if !reflect.DeepEqual(invalidURLs, test.expectedInvalidURLs) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", invalidURLs, test.expectedInvalidURLs, test.name)
+		}
+	}
+}
+
+func TestFilterAndFormatHostsWithPaths(t *testing.T) {
+	var (
+		ingressClassNameFilter = "traefik"
+		prefix                 = v1.PathTypePrefix
+		exact                  = v1.PathTypeExact
+		implementationSpecific = v1.PathTypeImplementationSpecific
+	)
+
+	newIngress := func(host string, paths ...v1.HTTPIngressPath) v1.Ingress {
+		ingress := v1.Ingress{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name:      host,
+				Namespace: "test-namespace",
+			},
+			Spec: v1.IngressSpec{
+				IngressClassName: &ingressClassNameFilter,
+				TLS: []v1.IngressTLS{
+					{
+						Hosts: []string{host},
+					},
+				},
+				Rules: []v1.IngressRule{
+					{
+						Host: host,
+					},
+				},
+			},
+		}
+
+		if len(paths) > 0 {
+			ingress.Spec.Rules[0].HTTP = &v1.HTTPIngressRuleValue{Paths: paths}
+		}
+		return ingress
+	}
+
+	ingressList := v1.IngressList{
+		Items: []v1.Ingress{
+			newIngress("test-app-1.sandbox.platform.hmcts.net"),
+			newIngress("test-app-2.sandbox.platform.hmcts.net",
+				v1.HTTPIngressPath{Path: "/", PathType: &prefix},
+				v1.HTTPIngressPath{Path: "/case-api/", PathType: &prefix},
+				v1.HTTPIngressPath{Path: "/health", PathType: &exact},
+				v1.HTTPIngressPath{Path: "/case-ui(/|$)(.*)", PathType: &implementationSpecific},
+				v1.HTTPIngressPath{Path: "/static/.*", PathType: &implementationSpecific},
+			),
+			newIngress("test-app-3.sandbox.platform.hmcts.net",
+				v1.HTTPIngressPath{Path: "/health", PathType: &exact},
+			),
+		},
+	}
+
+	tests := []struct {
+		name         string
+		pathAware    bool
+		urlTemplates []string
+		expectedList []string
+	}{
+		{
+			name: "paths ignored",
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback",
+			},
+		},
+		{
+			name:      "path aware",
+			pathAware: true,
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback",
+				"https://test-app-2.sandbox.platform.hmcts.net/case-api/oauth-proxy/callback",
+				"https://test-app-2.sandbox.platform.hmcts.net/case-ui/oauth-proxy/callback",
+				"https://test-app-2.sandbox.platform.hmcts.net/static/oauth-proxy/callback",
+			},
+		},
+		{
+			name:         "path aware with a template using the path",
+			pathAware:    true,
+			urlTemplates: []string{"https://{{ .Host }}{{ .Path }}/signin-oidc"},
+			expectedList: []string{
+				"https://test-app-1.sandbox.platform.hmcts.net/signin-oidc",
+				"https://test-app-2.sandbox.platform.hmcts.net/signin-oidc",
+				"https://test-app-2.sandbox.platform.hmcts.net/case-api/signin-oidc",
+				"https://test-app-2.sandbox.platform.hmcts.net/case-ui/signin-oidc",
+				"https://test-app-2.sandbox.platform.hmcts.net/static/signin-oidc",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := newTestSyncer(".*", test.urlTemplates...)
+		syncer.Spec.Target.PathAware = test.pathAware
+
+		if list, err := formatIngressHosts(&ingressList, syncer); err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+		} else if !reflect.DeepEqual(list, test.expectedList) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", list, test.expectedList, test.name)
+		}
+	}
+}
+
+func TestFilterAndFormatHostsWithStaticURLs(t *testing.T) {
+	hosts := []v1beta1.Host{
+		{Host: "test-app-1.sandbox.platform.hmcts.net", Scheme: "https", Kind: "Ingress"},
+	}
+
+	syncer := newTestSyncer(".*", "https://{{ .Host }}/oauth-proxy/callback")
+	syncer.Spec.Target.StaticURLs = []string{
+		"http://localhost:3000/oauth-proxy/callback",
+		"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+		"https://login.example.com/callback",
+		"http://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback",
+	}
+
+	expectedURLs := ReplyURLs{
+		v1beta1.PlatformWeb: {
+			"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+			"http://localhost:3000/oauth-proxy/callback",
+			"https://login.example.com/callback",
+		},
+	}
+	expectedInvalidURLs := []string{"http://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"}
+
+	replyURLs, invalidURLs, err := FilterAndFormatHosts(hosts, syncer)
+	if err != nil {
+		t.Errorf("Unexpected error %v\nTest: %s\n", err, strings.ToLower(t.Name()))
+	} else if !reflect.DeepEqual(replyURLs, expectedURLs) || !reflect.DeepEqual(invalidURLs, expectedInvalidURLs) {
+		t.Errorf("Result %v %v not equal to the expected result %v %v\nTest: %s\n",
+			replyURLs, invalidURLs, expectedURLs, expectedInvalidURLs, strings.ToLower(t.Name()))
+	}
+}
@@ -0,0 +1,24 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+)
+
+// EnvVarNotFoundError is returned when the environment variable holding a secret isn't set
+type EnvVarNotFoundError struct {
+	Name string
+}
+
+func (err EnvVarNotFoundError) Error() string {
+	return fmt.Sprintf("%s environment variable not found", err.Name)
+}
+
+func GetSecretFromEnv(envVarName string) (secret *string, err error) {
+	value, found := os.LookupEnv(envVarName)
+	if !found {
+		return nil, EnvVarNotFoundError{Name: envVarName}
+	}
+
+	return &value, nil
+}
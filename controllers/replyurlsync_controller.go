@@ -0,0 +1,427 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+/*
+ReplyURLSyncReconciler reconciles a ReplyURLSync object. Changes to the resources hosts are
+read from are mapped to the ReplyURLSyncs that read them, so every change is synced by working
+out all the reply URLs of a ReplyURLSync and converging its app registration with them.
+*/
+type ReplyURLSyncReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	/*
+		CoalescingWindow is how long a ReplyURLSync waits to be synced after a change to a resource
+		it reads hosts from, so a burst of changes, e.g. a Flux reconcile creating many ingresses,
+		is synced with a single write by each ReplyURLSync. Coalescing is per ReplyURLSync, so
+		ReplyURLSyncs sharing an app registration still write to it once each. Changes to the
+		ReplyURLSync itself are synced straight away.
+	*/
+	CoalescingWindow time.Duration
+	// Recorder records events on ReplyURLSyncs, such as conflicts with other writers of their app registration
+	Recorder record.EventRecorder
+
+	controller controller.Controller
+	restMapper meta.RESTMapper
+	// watched are the sources watched once a ReplyURLSync uses them, such as the kinds declared in its resources
+	watched map[ObjectSource]bool
+	lock    sync.Mutex
+	/*
+		hostSources are the sources hosts are read from. The hosts of every source are used to work
+		out the reply URLs a ReplyURLSync manages, so cleaning up after a resource of one source is
+		deleted doesn't remove the reply URLs of the others.
+	*/
+	hostSources     []hostSource
+	hostSourcesLock sync.RWMutex
+}
+
+//+kubebuilder:rbac:groups=appregistrations.azure.hmcts.net,resources=replyurlsyncs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=appregistrations.azure.hmcts.net,resources=replyurlsyncs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=appregistrations.azure.hmcts.net,resources=replyurlsyncs/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile syncs the reply URLs of the app registration of a ReplyURLSync with the hosts on the cluster
+func (r *ReplyURLSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	replyURLSync := v1beta1.ReplyURLSync{}
+
+	if err := r.Get(ctx, req.NamespacedName, &replyURLSync); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !replyURLSync.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, replyURLSync)
+	}
+
+	// The finalizer is only needed to remove the reply URLs of the sync when it is deleted
+	deleteURLs := replyURLSync.Spec.DeletionPolicy == v1beta1.DeletionPolicyDelete
+	if deleteURLs != controllerutil.ContainsFinalizer(&replyURLSync, v1beta1.ReplyURLSyncFinalizer) {
+		patch := client.MergeFrom(replyURLSync.DeepCopy())
+		if deleteURLs {
+			controllerutil.AddFinalizer(&replyURLSync, v1beta1.ReplyURLSyncFinalizer)
+		} else {
+			controllerutil.RemoveFinalizer(&replyURLSync, v1beta1.ReplyURLSyncFinalizer)
+		}
+
+		if err := r.Patch(ctx, &replyURLSync, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	/*
+		Kinds of resource declared by the sync, and Services if it reads their hosts, are watched before
+		its hosts are listed, kinds that can't be watched yet are retried with a backoff after syncing
+		the hosts of the others
+	*/
+	watchErr := r.watchResources(replyURLSync)
+
+	if err := syncReplyURLs(ctx, r.Client, r.Recorder, r.listHostSources(), replyURLSync); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, watchErr
+}
+
+/*
+finalize removes the reply URLs of a ReplyURLSync that is being deleted with the Delete deletion
+policy, then removes its finalizer so it can be deleted. The finalizer is removed straight away
+if the deletion policy has been changed to Retain since it was added.
+*/
+func (r *ReplyURLSyncReconciler) finalize(ctx context.Context, replyURLSync v1beta1.ReplyURLSync) error {
+	if !controllerutil.ContainsFinalizer(&replyURLSync, v1beta1.ReplyURLSyncFinalizer) {
+		return nil
+	}
+
+	if replyURLSync.Spec.DeletionPolicy == v1beta1.DeletionPolicyDelete {
+		if err := deleteReplyURLs(ctx, r.Client, r.Recorder, r.listHostSources(), replyURLSync); err != nil {
+			return err
+		}
+	}
+
+	patch := client.MergeFrom(replyURLSync.DeepCopy())
+	controllerutil.RemoveFinalizer(&replyURLSync, v1beta1.ReplyURLSyncFinalizer)
+	return r.Patch(ctx, &replyURLSync, patch)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReplyURLSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.restMapper = mgr.GetRESTMapper()
+	r.watched = map[ObjectSource]bool{}
+
+	if err := indexIngressFields(mgr); err != nil {
+		return err
+	}
+
+	r.addHostSource(ingressSource{})
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1beta1.ReplyURLSync{}, builder.WithPredicates(specChangedPredicate{})).
+		Watches(&source.Kind{Type: &v1.Ingress{}}, r.enqueueCoalesced(r.syncsForIngress),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(&source.Kind{Type: &v1.IngressClass{}}, r.enqueueCoalesced(r.syncsForIngressClass),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, r.enqueueCoalesced(r.syncsForNamespace),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	r.controller = c
+	return nil
+}
+
+/*
+WatchSource watches the resources of an ObjectSource using their preferred version, or the
+version read by a versionedSource, and reads hosts from them. A NoKindMatchError is returned
+when their CRD isn't installed. Sources can be watched once the manager has started.
+*/
+func (r *ReplyURLSyncReconciler) WatchSource(objectSource ObjectSource) error {
+	var (
+		groupKind = objectSource.groupKind()
+		versions  []string
+	)
+
+	if versioned, ok := objectSource.(versionedSource); ok {
+		versions = append(versions, versioned.version())
+	}
+
+	mapping, err := r.restMapper.RESTMapping(groupKind, versions...)
+	if err != nil {
+		return err
+	}
+
+	watched := &watchedSource{source: objectSource, gvk: mapping.GroupVersionKind}
+
+	if err := r.controller.Watch(&source.Kind{Type: watched.newObject()}, r.enqueueCoalesced(r.syncsForObject(objectSource)),
+		predicate.ResourceVersionChangedPredicate{}); err != nil {
+		return err
+	}
+
+	r.addHostSource(watched)
+
+	if dependent, ok := objectSource.(dependentSource); ok {
+		return r.watchDependencies(dependent)
+	}
+	return nil
+}
+
+/*
+watchDependencies watches the other kinds of resource the hosts of a dependentSource depend
+on, mapping their changes to the ReplyURLSyncs using the source. Kinds whose CRD isn't
+installed are skipped, as resources of the source can't refer to them.
+*/
+func (r *ReplyURLSyncReconciler) watchDependencies(dependent dependentSource) error {
+	for _, groupKind := range dependent.dependencies() {
+		mapping, err := r.restMapper.RESTMapping(groupKind)
+		if meta.IsNoMatchError(err) {
+			workerLog.Info("CRD not installed, skipping dependency", "kind", groupKind.String())
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(mapping.GroupVersionKind)
+
+		if err := r.controller.Watch(&source.Kind{Type: obj}, r.enqueueCoalesced(r.syncsUsingSource(dependent)),
+			predicate.ResourceVersionChangedPredicate{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+watchResources watches every kind of resource declared in the resources of a ReplyURLSync, and
+Services when it reads hosts from them. Services are only watched once a sync uses them, as
+every Service on the cluster would be cached otherwise.
+*/
+func (r *ReplyURLSyncReconciler) watchResources(replyURLSync v1beta1.ReplyURLSync) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var errs []error
+	if ServiceSource.usedBy(replyURLSync) && !r.watched[ServiceSource] {
+		if err := r.WatchSource(ServiceSource); err != nil {
+			errs = append(errs, err)
+		} else {
+			workerLog.Info("Watching resources", "apiVersion", "v1", "kind", "Service")
+			r.watched[ServiceSource] = true
+		}
+	}
+
+	for _, resource := range replyURLSync.Spec.Source.Resources {
+		source := resourceSource{gvk: resource.GroupVersionKind()}
+		if r.watched[source] {
+			continue
+		}
+
+		if err := r.WatchSource(source); err != nil {
+			if meta.IsNoMatchError(err) {
+				workerLog.Info("Resource kind not found, it will be watched once its CRD is installed",
+					"ReplyURLSync", client.ObjectKeyFromObject(&replyURLSync), "apiVersion", resource.APIVersion, "kind", resource.Kind)
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		workerLog.Info("Watching resources", "apiVersion", resource.APIVersion, "kind", resource.Kind)
+		r.watched[source] = true
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// syncsForIngress returns a request for every ReplyURLSync selecting the class of an ingress or the controller of its class
+func (r *ReplyURLSyncReconciler) syncsForIngress(obj client.Object) []reconcile.Request {
+	ctx := context.Background()
+
+	classes, err := listIngressClasses(ctx, r.Client)
+	if err != nil {
+		workerLog.Error(err, "Couldn't list ingress classes")
+		return nil
+	}
+
+	replyURLSyncs, err := listIngressReplyURLSync(ctx, r.Client, classes, *obj.(*v1.Ingress))
+	if err != nil {
+		workerLog.Error(err, "Couldn't list ReplyURLSyncs")
+		return nil
+	}
+
+	return syncRequests(replyURLSyncs, func(v1beta1.ReplyURLSync) bool { return true })
+}
+
+/*
+syncsForIngressClass returns a request for every ReplyURLSync reading ingresses, as the
+controller of a class or the default class changing can change the ingresses they select
+*/
+func (r *ReplyURLSyncReconciler) syncsForIngressClass(client.Object) []reconcile.Request {
+	replyURLSyncList, err := listReplyURLSync(context.Background(), r.Client)
+	if err != nil {
+		workerLog.Error(err, "Couldn't list ReplyURLSyncs")
+		return nil
+	}
+
+	return syncRequests(replyURLSyncList.Items, func(replyURLSync v1beta1.ReplyURLSync) bool {
+		return replyURLSync.Spec.Source.IngressClassNames() != nil || replyURLSync.Spec.Source.IngressController != ""
+	})
+}
+
+// syncsForNamespace returns a request for every ReplyURLSync with a namespace selector, which may select the namespace
+func (r *ReplyURLSyncReconciler) syncsForNamespace(client.Object) []reconcile.Request {
+	replyURLSyncList, err := listReplyURLSync(context.Background(), r.Client)
+	if err != nil {
+		workerLog.Error(err, "Couldn't list ReplyURLSyncs")
+		return nil
+	}
+
+	return syncRequests(replyURLSyncList.Items, func(replyURLSync v1beta1.ReplyURLSync) bool {
+		return replyURLSync.Spec.Source.NamespaceSelector != nil
+	})
+}
+
+/*
+syncsForObject returns a function giving a request for every ReplyURLSync that reads hosts
+from a resource of an ObjectSource. It is called with both the old and new resource when
+one is updated, so syncs are requested when the resource stops giving them hosts too.
+*/
+func (r *ReplyURLSyncReconciler) syncsForObject(objectSource ObjectSource) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		ctx := context.Background()
+
+		replyURLSyncList, err := listReplyURLSync(ctx, r.Client)
+		if err != nil {
+			workerLog.Error(err, "Couldn't list ReplyURLSyncs")
+			return nil
+		}
+
+		return syncRequests(replyURLSyncList.Items, func(replyURLSync v1beta1.ReplyURLSync) bool {
+			// Resources aren't read for syncs not using the source, as reading them may need other resources
+			if !objectSource.usedBy(replyURLSync) {
+				return false
+			}
+
+			hosts, err := objectSource.objectHosts(ctx, r.Client, obj.(*unstructured.Unstructured), replyURLSync)
+			if err != nil {
+				workerLog.Error(err, "Couldn't read hosts", "kind", objectSource.groupKind().String(),
+					"namespace", obj.GetNamespace(), "name", obj.GetName())
+			}
+			// Syncs are requested when hosts can't be read so their status shows the error
+			return err != nil || len(hosts) > 0
+		})
+	}
+}
+
+// syncsUsingSource returns a function giving a request for every ReplyURLSync reading hosts from an ObjectSource
+func (r *ReplyURLSyncReconciler) syncsUsingSource(objectSource ObjectSource) handler.MapFunc {
+	return func(client.Object) []reconcile.Request {
+		replyURLSyncList, err := listReplyURLSync(context.Background(), r.Client)
+		if err != nil {
+			workerLog.Error(err, "Couldn't list ReplyURLSyncs")
+			return nil
+		}
+
+		return syncRequests(replyURLSyncList.Items, objectSource.usedBy)
+	}
+}
+
+/*
+enqueueCoalesced returns an event handler adding the requests given by mapFunc once the
+coalescing window has passed. The queue only adds a request for a ReplyURLSync once however
+many times it is added before then.
+*/
+func (r *ReplyURLSyncReconciler) enqueueCoalesced(mapFunc handler.MapFunc) handler.EventHandler {
+	enqueue := func(q workqueue.RateLimitingInterface, obj client.Object) {
+		for _, request := range mapFunc(obj) {
+			q.AddAfter(request, r.CoalescingWindow)
+		}
+	}
+
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, e.ObjectOld)
+			enqueue(q, e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+			enqueue(q, e.Object)
+		},
+	}
+}
+
+// syncRequests returns a request for each of replyURLSyncs that is selected
+func syncRequests(replyURLSyncs []v1beta1.ReplyURLSync, selected func(v1beta1.ReplyURLSync) bool) (requests []reconcile.Request) {
+	for _, replyURLSync := range replyURLSyncs {
+		if selected(replyURLSync) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&replyURLSync)})
+		}
+	}
+	return requests
+}
+
+/*
+specChangedPredicate passes changes to the spec of a ReplyURLSync, its deletion and the periodic
+resyncs, which are used to put back reply URLs changed on the app registration outside the
+cluster. Other updates to the status and metadata are skipped so recording the outcome of a
+sync doesn't start another one.
+*/
+type specChangedPredicate struct {
+	predicate.Funcs
+}
+
+func (specChangedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	resync := e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion()
+	deleted := e.ObjectNew.GetDeletionTimestamp() != nil
+	return resync || deleted || e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
+}
@@ -0,0 +1,353 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestReplyURLSyncRequests(t *testing.T) {
+	newReplyURLSync := func(name string, source v1beta1.SourceSpec) *v1beta1.ReplyURLSync {
+		return &v1beta1.ReplyURLSync{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "admin"},
+			Spec:       v1beta1.ReplyURLSyncSpec{Source: source},
+		}
+	}
+
+	ingressClassName := "traefik-private"
+	ingress := &v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: "test-namespace"},
+		Spec: v1.IngressSpec{
+			IngressClassName: &ingressClassName,
+			Rules:            []v1.IngressRule{{Host: "test-app.sandbox.platform.hmcts.net"}},
+		},
+	}
+
+	service := newTestObject("serving.knative.dev/v1", "Service", "test-namespace", "test-app", map[string]interface{}{})
+	service.Object["status"] = map[string]interface{}{"url": "https://test-app.sandbox.platform.hmcts.net"}
+
+	s := scheme.Scheme
+	if err := v1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(
+			&v1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "traefik-private"},
+				Spec:       v1.IngressClassSpec{Controller: "traefik.io/ingress-controller"},
+			},
+			newReplyURLSync("public", v1beta1.SourceSpec{IngressClassFilter: "traefik"}),
+			newReplyURLSync("public-and-private", v1beta1.SourceSpec{IngressClasses: []string{"traefik", "traefik-private"}}),
+			newReplyURLSync("traefik", v1beta1.SourceSpec{IngressController: "traefik.io/ingress-controller"}),
+			newReplyURLSync("knative", v1beta1.SourceSpec{Knative: &v1beta1.KnativeSource{Services: true}}),
+			newReplyURLSync("gateway-api", v1beta1.SourceSpec{HTTPRoute: &v1beta1.HTTPRouteSource{GatewayClassName: "istio"}}),
+			newReplyURLSync("namespaced", v1beta1.SourceSpec{
+				IngressClassFilter: "traefik",
+				NamespaceSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"team": "test"}},
+			}),
+		).
+		WithIndex(&v1beta1.ReplyURLSync{}, ingressClassFilterField, indexIngressClassNames).
+		WithIndex(&v1beta1.ReplyURLSync{}, ingressControllerField, indexIngressController).
+		Build()
+
+	r := &ReplyURLSyncReconciler{Client: c}
+
+	tests := []struct {
+		name             string
+		requests         []reconcile.Request
+		expectedRequests []string
+	}{
+		{
+			name:             "ingress",
+			requests:         r.syncsForIngress(ingress),
+			expectedRequests: []string{"admin/public-and-private", "admin/traefik"},
+		},
+		{
+			name:             "ingress class",
+			requests:         r.syncsForIngressClass(&v1.IngressClass{}),
+			expectedRequests: []string{"admin/namespaced", "admin/public", "admin/public-and-private", "admin/traefik"},
+		},
+		{
+			name:             "namespace",
+			requests:         r.syncsForNamespace(&metav1.PartialObjectMetadata{}),
+			expectedRequests: []string{"admin/namespaced"},
+		},
+		{
+			name:             "object source",
+			requests:         r.syncsForObject(KnativeServiceSource)(service),
+			expectedRequests: []string{"admin/knative"},
+		},
+		{
+			name:             "gateway",
+			requests:         r.syncsUsingSource(HTTPRouteSource.(dependentSource))(&unstructured.Unstructured{}),
+			expectedRequests: []string{"admin/gateway-api"},
+		},
+	}
+
+	for _, test := range tests {
+		var requests []string
+		for _, request := range test.requests {
+			requests = append(requests, request.String())
+		}
+
+		if !reflect.DeepEqual(requests, test.expectedRequests) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", requests, test.expectedRequests, test.name)
+		}
+	}
+}
+
+func TestSpecChangedPredicate(t *testing.T) {
+	newReplyURLSync := func(generation int64, resourceVersion string) client.Object {
+		return &v1beta1.ReplyURLSync{ObjectMeta: metav1.ObjectMeta{Generation: generation, ResourceVersion: resourceVersion}}
+	}
+
+	tests := []struct {
+		name     string
+		event    event.UpdateEvent
+		expected bool
+	}{
+		{
+			name:     "spec changed",
+			event:    event.UpdateEvent{ObjectOld: newReplyURLSync(1, "1"), ObjectNew: newReplyURLSync(2, "2")},
+			expected: true,
+		},
+		{
+			name:     "status changed",
+			event:    event.UpdateEvent{ObjectOld: newReplyURLSync(1, "1"), ObjectNew: newReplyURLSync(1, "2")},
+			expected: false,
+		},
+		{
+			name: "deleted",
+			event: event.UpdateEvent{
+				ObjectOld: newReplyURLSync(1, "1"),
+				ObjectNew: &v1beta1.ReplyURLSync{ObjectMeta: metav1.ObjectMeta{
+					Generation: 1, ResourceVersion: "2", DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name:     "resync",
+			event:    event.UpdateEvent{ObjectOld: newReplyURLSync(1, "1"), ObjectNew: newReplyURLSync(1, "1")},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		if result := (specChangedPredicate{}).Update(test.event); result != test.expected {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", result, test.expected, test.name)
+		}
+	}
+}
+
+// addAfterQueue is a queue that records the requests added after a delay rather than adding them
+type addAfterQueue struct {
+	workqueue.RateLimitingInterface
+	added     []interface{}
+	durations []time.Duration
+}
+
+func (q *addAfterQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.added = append(q.added, item)
+	q.durations = append(q.durations, duration)
+}
+
+func TestEnqueueCoalesced(t *testing.T) {
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "admin", Name: "test-reply-url-sync"}}
+
+	r := &ReplyURLSyncReconciler{CoalescingWindow: 5 * time.Second}
+	eventHandler := r.enqueueCoalesced(func(client.Object) []reconcile.Request {
+		return []reconcile.Request{request}
+	})
+
+	tests := []struct {
+		name     string
+		enqueue  func(q workqueue.RateLimitingInterface)
+		expected int
+	}{
+		{
+			name: "create",
+			enqueue: func(q workqueue.RateLimitingInterface) {
+				eventHandler.Create(event.CreateEvent{Object: &v1.Ingress{}}, q)
+			},
+			expected: 1,
+		},
+		{
+			name: "update",
+			enqueue: func(q workqueue.RateLimitingInterface) {
+				eventHandler.Update(event.UpdateEvent{ObjectOld: &v1.Ingress{}, ObjectNew: &v1.Ingress{}}, q)
+			},
+			expected: 2,
+		},
+		{
+			name: "delete",
+			enqueue: func(q workqueue.RateLimitingInterface) {
+				eventHandler.Delete(event.DeleteEvent{Object: &v1.Ingress{}}, q)
+			},
+			expected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		q := &addAfterQueue{}
+		test.enqueue(q)
+
+		if len(q.added) != test.expected {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", len(q.added), test.expected, test.name)
+		}
+
+		// Every request waits for the coalescing window, so the queue adds it once for a burst of changes
+		for i := range q.added {
+			if q.added[i] != request || q.durations[i] != r.CoalescingWindow {
+				t.Errorf("Result %v after %v not equal to the expected result %v after %v\nTest: %s\n",
+					q.added[i], q.durations[i], request, r.CoalescingWindow, test.name)
+			}
+		}
+	}
+}
+
+func TestReplyURLSyncFinalizer(t *testing.T) {
+	s := scheme.Scheme
+	if err := v1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without credentials the app registration is never called
+	replyURLSync := &v1beta1.ReplyURLSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-reply-url-sync", Namespace: "admin"},
+		Spec: v1beta1.ReplyURLSyncSpec{
+			Source:         v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			DeletionPolicy: v1beta1.DeletionPolicyDelete,
+		},
+	}
+	key := client.ObjectKeyFromObject(replyURLSync)
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(replyURLSync).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &ReplyURLSyncReconciler{Client: c, Recorder: recorder}
+
+	reconcileSync := func() *v1beta1.ReplyURLSync {
+		if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Unexpected error %v\nTest: %s\n", err, t.Name())
+		}
+
+		replyURLSync := &v1beta1.ReplyURLSync{}
+		if err := c.Get(context.TODO(), key, replyURLSync); err != nil {
+			return nil
+		}
+		return replyURLSync
+	}
+
+	if replyURLSync = reconcileSync(); !controllerutil.ContainsFinalizer(replyURLSync, v1beta1.ReplyURLSyncFinalizer) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", replyURLSync.Finalizers, v1beta1.ReplyURLSyncFinalizer, "delete policy")
+	}
+
+	replyURLSync.Spec.DeletionPolicy = v1beta1.DeletionPolicyRetain
+	if err := c.Update(context.TODO(), replyURLSync); err != nil {
+		t.Fatal(err)
+	}
+	if replyURLSync = reconcileSync(); controllerutil.ContainsFinalizer(replyURLSync, v1beta1.ReplyURLSyncFinalizer) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", replyURLSync.Finalizers, []string{}, "retain policy")
+	}
+
+	replyURLSync.Spec.DeletionPolicy = v1beta1.DeletionPolicyDelete
+	controllerutil.AddFinalizer(replyURLSync, v1beta1.ReplyURLSyncFinalizer)
+	if err := c.Update(context.TODO(), replyURLSync); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(context.TODO(), replyURLSync); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reply URLs can't be removed without credentials so the sync is released with a warning
+	if replyURLSync = reconcileSync(); replyURLSync != nil {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", replyURLSync.Finalizers, nil, "deleted")
+	}
+	if len(recorder.Events) != 1 || !strings.Contains(<-recorder.Events, reasonDeleteFailed) {
+		t.Errorf("Expected a %s event\nTest: %s\n", reasonDeleteFailed, "deleted")
+	}
+}
+
+// watchCountingController is a controller that counts the watches started on it
+type watchCountingController struct {
+	controller.Controller
+	watches int
+}
+
+func (c *watchCountingController) Watch(source.Source, handler.EventHandler, ...predicate.Predicate) error {
+	c.watches++
+	return nil
+}
+
+func TestWatchResources(t *testing.T) {
+	restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	restMapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+
+	newReplyURLSync := func(source v1beta1.SourceSpec) v1beta1.ReplyURLSync {
+		return v1beta1.ReplyURLSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-reply-url-sync", Namespace: "admin"},
+			Spec:       v1beta1.ReplyURLSyncSpec{Source: source},
+		}
+	}
+
+	c := &watchCountingController{}
+	r := &ReplyURLSyncReconciler{controller: c, restMapper: restMapper, watched: map[ObjectSource]bool{}}
+
+	tests := []struct {
+		name            string
+		replyURLSync    v1beta1.ReplyURLSync
+		expectedWatches int
+	}{
+		{
+			name:         "services not used",
+			replyURLSync: newReplyURLSync(v1beta1.SourceSpec{ExternalDNS: &v1beta1.ExternalDNSSource{DNSEndpoints: true}}),
+		},
+		{
+			name:            "services used",
+			replyURLSync:    newReplyURLSync(v1beta1.SourceSpec{ExternalDNS: &v1beta1.ExternalDNSSource{Services: true}}),
+			expectedWatches: 1,
+		},
+		{
+			name:            "services already watched",
+			replyURLSync:    newReplyURLSync(v1beta1.SourceSpec{ExternalDNS: &v1beta1.ExternalDNSSource{Services: true}}),
+			expectedWatches: 1,
+		},
+	}
+
+	for _, test := range tests {
+		if err := r.watchResources(test.replyURLSync); err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+		}
+
+		// Hosts are read from every source that is watched
+		if c.watches != test.expectedWatches || len(r.listHostSources()) != test.expectedWatches {
+			t.Errorf("Result %v watches %v sources not equal to the expected result %v\nTest: %s\n",
+				c.watches, len(r.listHostSources()), test.expectedWatches, test.name)
+		}
+	}
+}
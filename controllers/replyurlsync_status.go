@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	azureGraph "github.com/hmcts/reply-urls-operator/controllers/pkg/azure"
+	"github.com/hmcts/reply-urls-operator/controllers/pkg/secrets"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons used for the conditions and events on a ReplyURLSync
+const (
+	reasonConflict            = "Conflict"
+	reasonCredentialsResolved = "CredentialsResolved"
+	reasonDeleted             = "ReplyURLsDeleted"
+	reasonDeleteFailed        = "DeleteFailed"
+	reasonMissingField        = "MissingField"
+	reasonOwnedURLsSeeded     = "OwnedURLsSeeded"
+	reasonSecretNotFound      = "SecretNotFound"
+	reasonSecretUnavailable   = "SecretUnavailable"
+	reasonSynced              = "Synced"
+	reasonSyncFailed          = "SyncFailed"
+	reasonUnmanagedURLs       = "UnmanagedURLs"
+)
+
+// syncResult holds the reply URLs affected by a sync
+type syncResult struct {
+	// managedURLs are the reply URLs generated for the hosts on the cluster, leaving out static URLs
+	managedURLs []string
+	// syncedHosts are the hosts on the cluster the managed reply URLs were generated for
+	syncedHosts []string
+	addedURLs   []string
+	removedURLs []string
+	invalidURLs []string
+	// unmanagedURLs are the reply URLs the operator didn't add, only found with the Report unmanaged URL policy
+	unmanagedURLs []string
+	// conflicts is the number of times the app registration was changed by another writer while syncing
+	conflicts int
+	// ownedURLs are the reply URLs the operator owns after the sync by platform
+	ownedURLs azureGraph.ReplyURLs
+}
+
+/*
+updateSyncStatus records the outcome of a sync on the status of the ReplyURLSync.
+credsErr is the error returned when resolving the credentials, syncErr is the error
+returned when updating the app registration. The status is only written if the
+ReplyURLSync hasn't changed since it was read, so owned reply URLs recorded by a
+newer sync aren't overwritten with a stale ledger.
+*/
+func updateSyncStatus(ctx context.Context, c client.Client, syncer v1beta1.ReplyURLSync, result syncResult, credsErr error, syncErr error) error {
+	var (
+		patch  = client.MergeFromWithOptions(syncer.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		status = &syncer.Status
+	)
+
+	setCondition := func(conditionType string, conditionStatus metav1.ConditionStatus, reason string, message string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: syncer.Generation,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	status.ObservedGeneration = syncer.Generation
+
+	switch {
+	case credsErr != nil:
+		reason := credentialsReason(credsErr)
+
+		setCondition(v1beta1.ConditionCredentialsResolved, metav1.ConditionFalse, reason, credsErr.Error())
+		setCondition(v1beta1.ConditionSynced, metav1.ConditionFalse, reason, "Credentials could not be resolved")
+		setCondition(v1beta1.ConditionDegraded, metav1.ConditionTrue, reason, credsErr.Error())
+		setCondition(v1beta1.ConditionReady, metav1.ConditionFalse, reason, credsErr.Error())
+		status.LastError = credsErr.Error()
+
+	case syncErr != nil:
+		reason := reasonSyncFailed
+		if errors.As(syncErr, &azureGraph.ConflictError{}) {
+			reason = reasonConflict
+		}
+
+		setCondition(v1beta1.ConditionCredentialsResolved, metav1.ConditionTrue, reasonCredentialsResolved, "")
+		setCondition(v1beta1.ConditionSynced, metav1.ConditionFalse, reason, syncErr.Error())
+		setCondition(v1beta1.ConditionDegraded, metav1.ConditionTrue, reason, syncErr.Error())
+		setCondition(v1beta1.ConditionReady, metav1.ConditionFalse, reason, syncErr.Error())
+		status.LastError = syncErr.Error()
+		status.Conflicts = int32(result.conflicts)
+
+	default:
+		message := fmt.Sprintf("%d reply URLs added, %d removed", len(result.addedURLs), len(result.removedURLs))
+		now := metav1.Now()
+
+		setCondition(v1beta1.ConditionCredentialsResolved, metav1.ConditionTrue, reasonCredentialsResolved, "")
+		setCondition(v1beta1.ConditionSynced, metav1.ConditionTrue, reasonSynced, message)
+		setCondition(v1beta1.ConditionDegraded, metav1.ConditionFalse, reasonSynced, "")
+		setCondition(v1beta1.ConditionReady, metav1.ConditionTrue, reasonSynced, message)
+		status.LastError = ""
+		status.LastSyncTime = &now
+		status.AddedURLs = int32(len(result.addedURLs))
+		status.RemovedURLs = int32(len(result.removedURLs))
+		status.Conflicts = int32(result.conflicts)
+
+		// A sync without hosts clears the counts and hosts left by the last one
+		status.ManagedURLs = int32(len(result.managedURLs))
+		status.SyncedHosts = result.syncedHosts
+		status.InvalidURLs = result.invalidURLs
+
+		status.UnmanagedURLs = result.unmanagedURLs
+
+		// An empty ledger is still recorded so the next sync doesn't take ownership of managed reply URLs again
+		status.OwnedURLs = result.ownedURLs
+		if status.OwnedURLs == nil {
+			status.OwnedURLs = azureGraph.ReplyURLs{}
+		}
+	}
+
+	return c.Status().Patch(ctx, &syncer, patch)
+}
+
+func credentialsReason(err error) string {
+	switch err.(type) {
+	case secrets.EnvVarNotFoundError:
+		return reasonSecretNotFound
+	}
+
+	if isConfigError(err) {
+		return reasonMissingField
+	}
+	return reasonSecretUnavailable
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	azureGraph "github.com/hmcts/reply-urls-operator/controllers/pkg/azure"
+	"github.com/hmcts/reply-urls-operator/controllers/pkg/secrets"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateSyncStatus(t *testing.T) {
+	var (
+		app1URL = "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app2URL = "https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"
+	)
+
+	s := scheme.Scheme
+	if err := v1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	type expectedStatus struct {
+		ready       metav1.ConditionStatus
+		degraded    metav1.ConditionStatus
+		reason      string
+		lastError   string
+		managedURLs int32
+		addedURLs   int32
+		syncedHosts []string
+		ownedURLs   map[v1beta1.Platform][]string
+	}
+
+	tests := []struct {
+		name     string
+		result   syncResult
+		credsErr error
+		syncErr  error
+		expected expectedStatus
+	}{
+		{
+			name: "synced",
+			result: syncResult{
+				managedURLs: []string{app1URL},
+				syncedHosts: []string{"test-app-1.sandbox.platform.hmcts.net"},
+				addedURLs:   []string{app1URL},
+				ownedURLs:   azureGraph.ReplyURLs{v1beta1.PlatformWeb: {app1URL}},
+			},
+			expected: expectedStatus{
+				ready:       metav1.ConditionTrue,
+				degraded:    metav1.ConditionFalse,
+				reason:      reasonSynced,
+				managedURLs: 1,
+				addedURLs:   1,
+				syncedHosts: []string{"test-app-1.sandbox.platform.hmcts.net"},
+				ownedURLs:   map[v1beta1.Platform][]string{v1beta1.PlatformWeb: {app1URL}},
+			},
+		},
+		{
+			name:   "synced without hosts",
+			result: syncResult{removedURLs: []string{app1URL, app2URL}, ownedURLs: azureGraph.ReplyURLs{}},
+			expected: expectedStatus{
+				ready:     metav1.ConditionTrue,
+				degraded:  metav1.ConditionFalse,
+				reason:    reasonSynced,
+				ownedURLs: map[v1beta1.Platform][]string{},
+			},
+		},
+		{
+			name:     "missing field",
+			credsErr: azureGraph.FieldNotFoundError{Field: ".spec.credentials.clientID", Resource: "ReplyURLSync/test"},
+			expected: expectedStatus{
+				ready:       metav1.ConditionFalse,
+				degraded:    metav1.ConditionTrue,
+				reason:      reasonMissingField,
+				lastError:   "Field '.spec.credentials.clientID' is missing please add to your ReplyURLSync/test resource",
+				managedURLs: 2,
+				syncedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+				ownedURLs:   map[v1beta1.Platform][]string{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			},
+		},
+		{
+			name:     "secret not found",
+			credsErr: secrets.EnvVarNotFoundError{Name: "CLIENT_SECRET"},
+			expected: expectedStatus{
+				ready:       metav1.ConditionFalse,
+				degraded:    metav1.ConditionTrue,
+				reason:      reasonSecretNotFound,
+				lastError:   "CLIENT_SECRET environment variable not found",
+				managedURLs: 2,
+				syncedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+				ownedURLs:   map[v1beta1.Platform][]string{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			},
+		},
+		{
+			name:    "sync failed",
+			syncErr: errors.New("graph unavailable"),
+			expected: expectedStatus{
+				ready:       metav1.ConditionFalse,
+				degraded:    metav1.ConditionTrue,
+				reason:      reasonSyncFailed,
+				lastError:   "graph unavailable",
+				managedURLs: 2,
+				syncedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+				ownedURLs:   map[v1beta1.Platform][]string{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			},
+		},
+		{
+			name:    "conflict",
+			syncErr: azureGraph.ConflictError{ObjectID: "test-object-id", Attempts: 3},
+			expected: expectedStatus{
+				ready:       metav1.ConditionFalse,
+				degraded:    metav1.ConditionTrue,
+				reason:      reasonConflict,
+				lastError:   azureGraph.ConflictError{ObjectID: "test-object-id", Attempts: 3}.Error(),
+				managedURLs: 2,
+				syncedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+				ownedURLs:   map[v1beta1.Platform][]string{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// Each sync starts from the status left by a sync of two hosts
+		replyURLSync := &v1beta1.ReplyURLSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-reply-url-sync", Namespace: "admin", Generation: 2},
+			Status: v1beta1.ReplyURLSyncStatus{
+				ManagedURLs: 2,
+				SyncedHosts: []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+				OwnedURLs:   azureGraph.ReplyURLs{v1beta1.PlatformWeb: {app1URL, app2URL}},
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(replyURLSync).Build()
+
+		if err := updateSyncStatus(context.TODO(), c, *replyURLSync, test.result, test.credsErr, test.syncErr); err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		if err := c.Get(context.TODO(), client.ObjectKeyFromObject(replyURLSync), replyURLSync); err != nil {
+			t.Fatal(err)
+		}
+		status := replyURLSync.Status
+
+		ready := meta.FindStatusCondition(status.Conditions, v1beta1.ConditionReady)
+		degraded := meta.FindStatusCondition(status.Conditions, v1beta1.ConditionDegraded)
+		if ready == nil || degraded == nil {
+			t.Errorf("Result %v does not have the Ready and Degraded conditions\nTest: %s\n", status.Conditions, test.name)
+			continue
+		}
+
+		result := expectedStatus{
+			ready:       ready.Status,
+			degraded:    degraded.Status,
+			reason:      ready.Reason,
+			lastError:   status.LastError,
+			managedURLs: status.ManagedURLs,
+			addedURLs:   status.AddedURLs,
+			syncedHosts: status.SyncedHosts,
+			ownedURLs:   status.OwnedURLs,
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("Result %+v not equal to the expected result %+v\nTest: %s\n", result, test.expected, test.name)
+		}
+
+		if status.ObservedGeneration != replyURLSync.Generation {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", status.ObservedGeneration, replyURLSync.Generation, test.name)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/go-openapi/swag"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	azureGraph "github.com/hmcts/reply-urls-operator/controllers/pkg/azure"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+/*
+syncReplyURLs converges the app registration of a ReplyURLSync with the reply URLs of every
+host on the cluster it manages, adding the missing reply URLs and removing the ones of hosts
+that have gone in a single pass, and records the outcome on its status. Conflicts with other writers of the app registration
+are recorded as events on the ReplyURLSync. The reply URLs the operator adds are recorded as owned, so only they are
+removed when their hosts go.
+*/
+func syncReplyURLs(ctx context.Context, c client.Client, recorder record.EventRecorder, sources []hostSource, syncer v1beta1.ReplyURLSync) error {
+	clientSecretCreds, err := resolveCredentials(syncer)
+	if err != nil {
+		return handleCredentialsError(ctx, c, syncer, err)
+	}
+
+	// Syncs created before the defaulting webhook was added may not have their filters set
+	syncer.Spec.Default()
+	syncSpec := syncer.Spec
+
+	result := syncResult{}
+
+	managed, err := listManagedURLs(ctx, c, sources, syncer)
+	if err == nil {
+		var (
+			appRegistrations azureGraph.AppRegistrations
+			graphResult      azureGraph.SyncResult
+		)
+		if appRegistrations, err = newAppRegistrations(clientSecretCreds); err == nil {
+			graphResult, err = azureGraph.SyncAppRegistration(appRegistrations, azureGraph.PatchOptions{
+				ReplyURLs: managed.replyURLs,
+				Syncer:    syncer,
+			})
+		}
+		result.managedURLs = managed.hostURLs
+		result.syncedHosts = managed.hosts
+		result.addedURLs = graphResult.AddedURLs
+		result.removedURLs = graphResult.RemovedURLs
+		result.unmanagedURLs = graphResult.UnmanagedURLs
+		result.conflicts = graphResult.Conflicts
+		result.ownedURLs = graphResult.OwnedURLs
+		result.invalidURLs = managed.invalidURLs
+
+		if err == nil && len(graphResult.SeededURLs) > 0 {
+			recorder.Eventf(&syncer, corev1.EventTypeNormal, reasonOwnedURLsSeeded,
+				"No owned reply URLs were recorded, %d managed reply URLs already on app registration %s are now owned by the operator",
+				len(graphResult.SeededURLs), syncSpec.Target.ObjectID)
+		}
+	}
+
+	if conflictErr := (azureGraph.ConflictError{}); errors.As(err, &conflictErr) {
+		recorder.Event(&syncer, corev1.EventTypeWarning, reasonConflict, conflictErr.Error())
+	} else if result.conflicts > 0 {
+		recorder.Eventf(&syncer, corev1.EventTypeWarning, reasonConflict,
+			"The reply URLs of app registration %s were changed by another writer while syncing, synced after %d retries",
+			syncSpec.Target.ObjectID, result.conflicts)
+	}
+
+	if err == nil && len(result.unmanagedURLs) > 0 && !reflect.DeepEqual(result.unmanagedURLs, syncer.Status.UnmanagedURLs) {
+		recorder.Eventf(&syncer, corev1.EventTypeWarning, reasonUnmanagedURLs,
+			"%d reply URLs on app registration %s match the reply URL filter but weren't added by the operator",
+			len(result.unmanagedURLs), syncSpec.Target.ObjectID)
+	}
+
+	// The owned reply URLs are only recorded on the status, so the sync is retried when it can't be written
+	if statusErr := updateSyncStatus(ctx, c, syncer, result, nil, err); statusErr != nil {
+		workerLog.Error(statusErr, "Unable to update ReplyURLSync status", "ReplyURLSync", syncer.Name)
+		if err == nil {
+			err = statusErr
+		}
+	}
+
+	if result.addedURLs != nil {
+		workerLog.Info("Reply URLs added",
+			"URLs", result.addedURLs,
+			"object id", syncSpec.Target.ObjectID,
+			"ingressClassName", syncSpec.Source.IngressClassFilter,
+		)
+	}
+
+	if result.removedURLs != nil {
+		workerLog.Info("Reply URLs removed",
+			"URLs", result.removedURLs,
+			"object id", syncSpec.Target.ObjectID,
+			"ingressClassName", syncSpec.Source.IngressClassFilter,
+		)
+	}
+
+	return err
+}
+
+/*
+deleteReplyURLs removes the reply URLs a ReplyURLSync that is being deleted owns from its app
+registration, leaving the ones added by hand even if the sync manages them. Reply URLs also
+managed by another ReplyURLSync for the same app registration are kept. When the sync config is
+missing something needed to call Graph the reply URLs can't ever be removed, so they are left
+with a warning event rather than blocking the deletion.
+*/
+func deleteReplyURLs(ctx context.Context, c client.Client, recorder record.EventRecorder, sources []hostSource, syncer v1beta1.ReplyURLSync) error {
+	clientSecretCreds, err := resolveCredentials(syncer)
+	if err != nil {
+		if isConfigError(err) {
+			recorder.Eventf(&syncer, corev1.EventTypeWarning, reasonDeleteFailed, "Reply URLs left on the app registration: %s", err.Error())
+			return nil
+		}
+		return err
+	}
+
+	syncer.Spec.Default()
+	syncSpec := syncer.Spec
+
+	// Owned reply URLs are removed from the platform they were added to, including static URLs
+	replyURLs := azureGraph.ReplyURLs{}
+	for platform, urls := range syncer.Status.OwnedURLs {
+		replyURLs[platform] = append([]string{}, urls...)
+	}
+
+	replyURLSyncList, err := listReplyURLSync(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range replyURLSyncList.Items {
+		if other.UID == syncer.UID || other.Spec.Target.ObjectID != syncSpec.Target.ObjectID || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		other.Spec.Default()
+		otherManaged, err := listManagedURLs(ctx, c, sources, other)
+		if err != nil {
+			return err
+		}
+		otherURLs := otherManaged.replyURLs
+
+		for platform, urls := range replyURLs {
+			var kept []string
+			for _, url := range urls {
+				if !swag.ContainsStrings(otherURLs[platform], url) {
+					kept = append(kept, url)
+				}
+			}
+			replyURLs[platform] = kept
+		}
+	}
+
+	appRegistrations, err := newAppRegistrations(clientSecretCreds)
+	if err != nil {
+		recorder.Event(&syncer, corev1.EventTypeWarning, reasonDeleteFailed, err.Error())
+		return err
+	}
+
+	removedURLs, conflicts, err := azureGraph.RemoveAppReplyURLs(appRegistrations, azureGraph.PatchOptions{
+		ReplyURLs: replyURLs,
+		Syncer:    syncer,
+	})
+
+	if conflicts > 0 {
+		recorder.Eventf(&syncer, corev1.EventTypeWarning, reasonConflict,
+			"The reply URLs of app registration %s were changed by another writer while removing them, %d conflicts", syncSpec.Target.ObjectID, conflicts)
+	}
+
+	if err != nil {
+		recorder.Event(&syncer, corev1.EventTypeWarning, reasonDeleteFailed, err.Error())
+		return err
+	}
+
+	if removedURLs != nil {
+		workerLog.Info("Reply URLs removed",
+			"URLs", removedURLs,
+			"object id", syncSpec.Target.ObjectID,
+			"ReplyURLSync", syncer.Name,
+		)
+	}
+	recorder.Eventf(&syncer, corev1.EventTypeNormal, reasonDeleted, "%d reply URLs removed from the app registration", len(removedURLs))
+
+	return nil
+}
+
+// managedURLs are the reply URLs managed by a ReplyURLSync and the hosts they were generated for
+type managedURLs struct {
+	replyURLs azureGraph.ReplyURLs
+	// hosts are the hosts on the cluster the reply URLs were generated for, static URLs don't have one
+	hosts []string
+	// hostURLs are the reply URLs generated for hosts, leaving out static URLs
+	hostURLs    []string
+	invalidURLs []string
+}
+
+/*
+listManagedURLs returns the reply URLs generated from every host on the cluster that matches
+the sync config with the static URLs of the sync, and the ones that aren't managed as Azure
+wouldn't accept them
+*/
+func listManagedURLs(ctx context.Context, c client.Client, sources []hostSource, syncer v1beta1.ReplyURLSync) (managed managedURLs, err error) {
+	hosts, err := listHosts(ctx, c, sources, syncer)
+	if err != nil {
+		return managedURLs{}, err
+	}
+
+	if hosts, err = azureGraph.SyncedHosts(hosts, syncer); err != nil {
+		return managedURLs{}, err
+	}
+
+	if managed.replyURLs, managed.invalidURLs, err = azureGraph.FormatHosts(hosts, syncer); err != nil {
+		return managedURLs{}, err
+	}
+	managed.hostURLs = managed.replyURLs.All()
+	managed.invalidURLs = append(managed.invalidURLs, azureGraph.AddStaticURLs(managed.replyURLs, syncer)...)
+
+	for _, host := range hosts {
+		if !swag.ContainsStrings(managed.hosts, host.Host) {
+			managed.hosts = append(managed.hosts, host.Host)
+		}
+	}
+
+	if len(managed.invalidURLs) > 0 {
+		workerLog.Info("Invalid reply URLs not synced, Azure only accepts https reply URLs and http for localhost",
+			"URLs", managed.invalidURLs, "ReplyURLSync", syncer.Name)
+	}
+	return managed, nil
+}
+
+/*
+handleCredentialsError records a failure to resolve the credentials of a ReplyURLSync
+on its status. Errors in the sync config aren't returned as they won't be fixed by
+retrying, anything else is returned so the request is requeued.
+*/
+func handleCredentialsError(ctx context.Context, c client.Client, syncer v1beta1.ReplyURLSync, err error) error {
+	if statusErr := updateSyncStatus(ctx, c, syncer, syncResult{}, err, nil); statusErr != nil {
+		workerLog.Error(statusErr, "Unable to update ReplyURLSync status", "ReplyURLSync", syncer.Name)
+	}
+
+	if isConfigError(err) {
+		workerLog.Info("Missing configuration", "ReplyURLSync", syncer.Name, "error", err.Error())
+		return nil
+	}
+	return err
+}
+
+func listReplyURLSync(ctx context.Context, c client.Client) (replyURLSyncList *v1beta1.ReplyURLSyncList, err error) {
+	replyURLSyncList = &v1beta1.ReplyURLSyncList{}
+
+	err = c.List(ctx, replyURLSyncList)
+	if err != nil {
+		return nil, err
+	}
+	return replyURLSyncList, nil
+}
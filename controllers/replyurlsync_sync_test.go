@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	azureGraph "github.com/hmcts/reply-urls-operator/controllers/pkg/azure"
+	v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeAppRegistrations is an app registration that records the patches written to it
+type fakeAppRegistrations struct {
+	replyURLs azureGraph.ReplyURLs
+	patches   []azureGraph.ReplyURLs
+}
+
+func (apps *fakeAppRegistrations) GetReplyURLs(string) (azureGraph.ReplyURLs, error) {
+	replyURLs := azureGraph.ReplyURLs{}
+	for platform, urls := range apps.replyURLs {
+		replyURLs[platform] = append([]string{}, urls...)
+	}
+	return replyURLs, nil
+}
+
+func (apps *fakeAppRegistrations) PatchReplyURLs(_ string, replyURLs azureGraph.ReplyURLs) error {
+	apps.patches = append(apps.patches, replyURLs)
+	for platform, urls := range replyURLs {
+		apps.replyURLs[platform] = urls
+	}
+	return nil
+}
+
+// withAppRegistrations syncs to apps rather than Graph until the test has finished
+func withAppRegistrations(t *testing.T, apps azureGraph.AppRegistrations) {
+	previous := newAppRegistrations
+	newAppRegistrations = func(azureGraph.ClientSecretCredentials) (azureGraph.AppRegistrations, error) {
+		return apps, nil
+	}
+	t.Cleanup(func() { newAppRegistrations = previous })
+}
+
+func newTestIngress(name string, className string, annotations map[string]string) *v1.Ingress {
+	return &v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace", Annotations: annotations},
+		Spec: v1.IngressSpec{
+			IngressClassName: &className,
+			Rules:            []v1.IngressRule{{Host: name + ".sandbox.platform.hmcts.net"}},
+		},
+	}
+}
+
+func TestListManagedURLs(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(
+		newTestIngress("test-app-1", "traefik", nil),
+		newTestIngress("test-app-2", "traefik", map[string]string{v1beta1.PlatformAnnotation: "SPA"}),
+		newTestIngress("test-app-3", "nginx", nil),
+		newTestIngress("test-app-4", "traefik", map[string]string{v1beta1.IgnoreAnnotation: "true"}),
+	).Build()
+
+	expectedHosts := []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"}
+	expectedHostURLs := []string{
+		"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+		"https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback",
+	}
+
+	tests := []struct {
+		name                string
+		target              v1beta1.TargetSpec
+		expectedURLs        azureGraph.ReplyURLs
+		expectedInvalidURLs []string
+	}{
+		{
+			name: "ingress hosts",
+			expectedURLs: azureGraph.ReplyURLs{
+				v1beta1.PlatformWeb: {"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"},
+				v1beta1.PlatformSPA: {"https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"},
+			},
+		},
+		{
+			name: "static and invalid urls",
+			target: v1beta1.TargetSpec{
+				StaticURLs: []string{"https://login.example.com/callback", "http://login.example.com/callback"},
+			},
+			expectedURLs: azureGraph.ReplyURLs{
+				v1beta1.PlatformWeb: {
+					"https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback",
+					"https://login.example.com/callback",
+				},
+				v1beta1.PlatformSPA: {"https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"},
+			},
+			expectedInvalidURLs: []string{"http://login.example.com/callback"},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := v1beta1.ReplyURLSync{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-reply-url-sync", Namespace: "admin"},
+			Spec: v1beta1.ReplyURLSyncSpec{
+				Source: v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+				Target: test.target,
+			},
+		}
+		syncer.Spec.Default()
+
+		managed, err := listManagedURLs(context.TODO(), c, []hostSource{ingressSource{}}, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		if !reflect.DeepEqual(managed.replyURLs, test.expectedURLs) || !reflect.DeepEqual(managed.invalidURLs, test.expectedInvalidURLs) {
+			t.Errorf("Result %v %v not equal to the expected result %v %v\nTest: %s\n",
+				managed.replyURLs, managed.invalidURLs, test.expectedURLs, test.expectedInvalidURLs, test.name)
+		}
+
+		// Static URLs aren't generated for a host on the cluster
+		if !reflect.DeepEqual(managed.hosts, expectedHosts) || !reflect.DeepEqual(managed.hostURLs, expectedHostURLs) {
+			t.Errorf("Result %v %v not equal to the expected result %v %v\nTest: %s\n",
+				managed.hosts, managed.hostURLs, expectedHosts, expectedHostURLs, test.name)
+		}
+	}
+}
+
+// newTestCredentials returns credentials for a sync with the client secret in an environment variable
+func newTestCredentials(t *testing.T) v1beta1.CredentialsSpec {
+	t.Setenv("TEST_CLIENT_SECRET", "test-client-secret")
+
+	return v1beta1.CredentialsSpec{
+		TenantID:     "00000000-0000-0000-0000-000000000000",
+		ClientID:     "00000000-0000-0000-0000-000000000000",
+		ClientSecret: v1beta1.ClientSecret{EnvVarClientSecret: "TEST_CLIENT_SECRET"},
+	}
+}
+
+func TestReconcileConvergesAddsAndRemoves(t *testing.T) {
+	var (
+		app1URL = "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app2URL = "https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		userURL = "https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback"
+	)
+
+	s := scheme.Scheme
+	if err := v1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// test-app-2 has gone since the last sync and test-app-1 has been added
+	replyURLSync := &v1beta1.ReplyURLSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-reply-url-sync", Namespace: "admin"},
+		Spec: v1beta1.ReplyURLSyncSpec{
+			Source:      v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			Credentials: newTestCredentials(t),
+			Target:      v1beta1.TargetSpec{ObjectID: "11111111-1111-1111-1111-111111111111"},
+		},
+		Status: v1beta1.ReplyURLSyncStatus{OwnedURLs: azureGraph.ReplyURLs{v1beta1.PlatformWeb: {app2URL}}},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(replyURLSync, newTestIngress("test-app-1", "traefik", nil)).
+		Build()
+
+	apps := &fakeAppRegistrations{replyURLs: azureGraph.ReplyURLs{v1beta1.PlatformWeb: {app2URL, userURL}}}
+	withAppRegistrations(t, apps)
+
+	r := &ReplyURLSyncReconciler{Client: c, Recorder: record.NewFakeRecorder(10), hostSources: []hostSource{ingressSource{}}}
+	if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(replyURLSync)}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPatches := []azureGraph.ReplyURLs{{v1beta1.PlatformWeb: {userURL, app1URL}}}
+	if !reflect.DeepEqual(apps.patches, expectedPatches) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", apps.patches, expectedPatches, "one patch")
+	}
+
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(replyURLSync), replyURLSync); err != nil {
+		t.Fatal(err)
+	}
+	status := replyURLSync.Status
+	if status.AddedURLs != 1 || status.RemovedURLs != 1 {
+		t.Errorf("Result %d added %d removed not equal to the expected result 1 added 1 removed\nTest: %s\n",
+			status.AddedURLs, status.RemovedURLs, "status")
+	}
+
+	// The reply URL added by hand isn't owned, so it is kept when its host goes
+	expectedOwnedURLs := map[v1beta1.Platform][]string{v1beta1.PlatformWeb: {app1URL}}
+	if !reflect.DeepEqual(status.OwnedURLs, expectedOwnedURLs) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", status.OwnedURLs, expectedOwnedURLs, "owned urls")
+	}
+}
+
+func TestDeleteReplyURLs(t *testing.T) {
+	var (
+		app1URL  = "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app2URL  = "https://test-app-2.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app3URL  = "https://test-app-3.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		app4URL  = "https://test-app-4.sandbox.platform.hmcts.net/oauth-proxy/callback"
+		objectID = "11111111-1111-1111-1111-111111111111"
+	)
+
+	s := scheme.Scheme
+	if err := v1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// test-app-2 has gone since the last sync, its reply URL was added to the SPA platform
+	replyURLSync := &v1beta1.ReplyURLSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-reply-url-sync", Namespace: "admin", UID: "test-reply-url-sync"},
+		Spec: v1beta1.ReplyURLSyncSpec{
+			Source:         v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			Credentials:    newTestCredentials(t),
+			Target:         v1beta1.TargetSpec{ObjectID: objectID},
+			DeletionPolicy: v1beta1.DeletionPolicyDelete,
+		},
+		Status: v1beta1.ReplyURLSyncStatus{OwnedURLs: azureGraph.ReplyURLs{
+			v1beta1.PlatformWeb: {app1URL, app4URL},
+			v1beta1.PlatformSPA: {app2URL},
+		}},
+	}
+	// test-app-4 has moved to the class synced by another ReplyURLSync for the same app registration
+	otherReplyURLSync := &v1beta1.ReplyURLSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-reply-url-sync", Namespace: "admin", UID: "other-reply-url-sync"},
+		Spec: v1beta1.ReplyURLSyncSpec{
+			Source: v1beta1.SourceSpec{IngressClassFilter: "nginx"},
+			Target: v1beta1.TargetSpec{ObjectID: objectID},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(
+			replyURLSync,
+			otherReplyURLSync,
+			newTestIngress("test-app-1", "traefik", nil),
+			newTestIngress("test-app-3", "traefik", nil),
+			newTestIngress("test-app-4", "nginx", nil),
+		).
+		Build()
+
+	// The reply URL of test-app-3 was added by hand before the sync generated it
+	apps := &fakeAppRegistrations{replyURLs: azureGraph.ReplyURLs{
+		v1beta1.PlatformWeb: {app1URL, app2URL, app3URL, app4URL},
+		v1beta1.PlatformSPA: {app2URL},
+	}}
+	withAppRegistrations(t, apps)
+
+	if err := deleteReplyURLs(context.TODO(), c, record.NewFakeRecorder(10), []hostSource{ingressSource{}}, *replyURLSync); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only owned reply URLs that no other sync manages are removed, the ones added by hand are kept
+	expectedPatches := []azureGraph.ReplyURLs{{v1beta1.PlatformWeb: {app2URL, app3URL, app4URL}, v1beta1.PlatformSPA: {}}}
+	if !reflect.DeepEqual(apps.patches, expectedPatches) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", apps.patches, expectedPatches, t.Name())
+	}
+}
+
+func TestSyncReplyURLsStatusPatchFails(t *testing.T) {
+	appURL := "https://test-app-1.sandbox.platform.hmcts.net/oauth-proxy/callback"
+
+	s := scheme.Scheme
+	if err := v1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	replyURLSync := &v1beta1.ReplyURLSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-reply-url-sync", Namespace: "admin"},
+		Spec: v1beta1.ReplyURLSyncSpec{
+			Source:      v1beta1.SourceSpec{IngressClassFilter: "traefik"},
+			Credentials: newTestCredentials(t),
+			Target:      v1beta1.TargetSpec{ObjectID: "11111111-1111-1111-1111-111111111111"},
+		},
+		Status: v1beta1.ReplyURLSyncStatus{OwnedURLs: azureGraph.ReplyURLs{}},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(s).
+		WithObjects(replyURLSync, newTestIngress("test-app-1", "traefik", nil)).
+		Build()
+
+	// The sync is read from a cache that hasn't seen the latest change to it
+	stale := &v1beta1.ReplyURLSync{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(replyURLSync), stale); err != nil {
+		t.Fatal(err)
+	}
+	latest := stale.DeepCopy()
+	latest.Labels = map[string]string{"team": "test"}
+	if err := c.Update(context.TODO(), latest); err != nil {
+		t.Fatal(err)
+	}
+
+	apps := &fakeAppRegistrations{replyURLs: azureGraph.ReplyURLs{}}
+	withAppRegistrations(t, apps)
+
+	err := syncReplyURLs(context.TODO(), c, record.NewFakeRecorder(10), []hostSource{ingressSource{}}, *stale)
+	if !apierrors.IsConflict(err) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", err, "a conflict", "status not written")
+	}
+
+	expectedPatches := []azureGraph.ReplyURLs{{v1beta1.PlatformWeb: {appURL}}}
+	if !reflect.DeepEqual(apps.patches, expectedPatches) {
+		t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", apps.patches, expectedPatches, "graph written")
+	}
+}
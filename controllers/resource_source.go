@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resourceSource reads hosts from a kind of resource declared in the resources of ReplyURLSyncs
+type resourceSource struct {
+	gvk schema.GroupVersionKind
+}
+
+func (source resourceSource) groupKind() schema.GroupKind {
+	return source.gvk.GroupKind()
+}
+
+// version is the version of the resources declared by the ReplyURLSyncs, the JSONPath expressions are written for it
+func (source resourceSource) version() string {
+	return source.gvk.Version
+}
+
+// usedBy reports whether syncer declares a resource source of the kind
+func (source resourceSource) usedBy(syncer v1beta1.ReplyURLSync) bool {
+	for _, resource := range syncer.Spec.Source.Resources {
+		if resource.GroupVersionKind() == source.gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// objectHosts returns the hosts of obj for every resource source of the sync with the same kind that selects it
+func (source resourceSource) objectHosts(_ context.Context, _ client.Client, obj *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	for _, resource := range syncer.Spec.Source.Resources {
+		if resource.GroupVersionKind() != source.gvk {
+			continue
+		}
+
+		if resource.Class != "" {
+			classes, err := jsonPathValues(resource.ClassPath, obj)
+			if err != nil {
+				return nil, err
+			}
+			if !swag.ContainsStrings(classes, resource.Class) {
+				continue
+			}
+		}
+
+		hostnames, err := jsonPathValues(resource.HostsPath, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, hostname := range hostnames {
+			// Reply URLs can't be generated for wildcard hosts
+			if strings.HasPrefix(hostname, "*") {
+				continue
+			}
+			hosts = append(hosts, objectHost(obj, hostname))
+		}
+	}
+	return hosts, nil
+}
+
+// jsonPathValues returns the strings found in obj by a JSONPath expression, lists of strings are flattened
+func jsonPathValues(path string, obj *unstructured.Unstructured) (values []string, err error) {
+	parser, err := v1beta1.ParseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := parser.FindResults(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		for _, value := range result {
+			switch value := value.Interface().(type) {
+			case string:
+				values = append(values, value)
+			case []interface{}:
+				for _, item := range value {
+					if item, ok := item.(string); ok {
+						values = append(values, item)
+					}
+				}
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResourceSourceObjectHosts(t *testing.T) {
+	proxy := newTestObject("projectcontour.io/v1", "HTTPProxy", "test-namespace", "test-app", map[string]interface{}{
+		"ingressClassName": "contour",
+		"virtualhost": map[string]interface{}{
+			"fqdn": "test-app-1.sandbox.platform.hmcts.net",
+		},
+		"aliases": []interface{}{
+			"test-app-2.sandbox.platform.hmcts.net",
+			"*.sandbox.platform.hmcts.net",
+		},
+	})
+
+	source := resourceSource{gvk: proxy.GroupVersionKind()}
+
+	tests := []struct {
+		name          string
+		resources     []v1beta1.ResourceSource
+		expectedHosts []string
+	}{
+		{
+			name: "no resource sources",
+		},
+		{
+			name: "hosts path",
+			resources: []v1beta1.ResourceSource{
+				{APIVersion: "projectcontour.io/v1", Kind: "HTTPProxy", HostsPath: ".spec.virtualhost.fqdn"},
+			},
+			expectedHosts: []string{"test-app-1.sandbox.platform.hmcts.net"},
+		},
+		{
+			name: "hosts path to a list",
+			resources: []v1beta1.ResourceSource{
+				{APIVersion: "projectcontour.io/v1", Kind: "HTTPProxy", HostsPath: "{.spec.aliases}"},
+			},
+			expectedHosts: []string{"test-app-2.sandbox.platform.hmcts.net"},
+		},
+		{
+			name: "matching class",
+			resources: []v1beta1.ResourceSource{
+				{APIVersion: "projectcontour.io/v1", Kind: "HTTPProxy", HostsPath: "{.spec.virtualhost.fqdn}", ClassPath: "{.spec.ingressClassName}", Class: "contour"},
+			},
+			expectedHosts: []string{"test-app-1.sandbox.platform.hmcts.net"},
+		},
+		{
+			name: "other class",
+			resources: []v1beta1.ResourceSource{
+				{APIVersion: "projectcontour.io/v1", Kind: "HTTPProxy", HostsPath: "{.spec.virtualhost.fqdn}", ClassPath: "{.spec.ingressClassName}", Class: "contour-private"},
+			},
+		},
+		{
+			name: "missing field",
+			resources: []v1beta1.ResourceSource{
+				{APIVersion: "projectcontour.io/v1", Kind: "HTTPProxy", HostsPath: "{.spec.host}"},
+			},
+		},
+		{
+			name: "other version",
+			resources: []v1beta1.ResourceSource{
+				{APIVersion: "projectcontour.io/v1beta1", Kind: "HTTPProxy", HostsPath: "{.spec.virtualhost.fqdn}"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source.Resources = test.resources
+
+		hosts, err := source.objectHosts(context.TODO(), fake.NewClientBuilder().Build(), proxy, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostnames []string
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Host)
+		}
+
+		if !reflect.DeepEqual(hostnames, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", hostnames, test.expectedHosts, test.name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+/*
+selectHosts returns the hosts from resources in the namespaces selected by the namespace
+selector of a ReplyURLSync that match its label selector. Selectors that aren't set select everything.
+*/
+func selectHosts(ctx context.Context, c client.Client, syncer v1beta1.ReplyURLSync, hosts []v1beta1.Host) (selected []v1beta1.Host, err error) {
+	// A nil label selector selects nothing so everything is selected when one isn't set
+	labelSelector := labels.Everything()
+	if syncer.Spec.Source.LabelSelector != nil {
+		if labelSelector, err = metav1.LabelSelectorAsSelector(syncer.Spec.Source.LabelSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	namespaces, err := selectNamespaces(ctx, c, syncer)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		if namespaces != nil && !namespaces.Has(host.Namespace) {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(host.Labels)) {
+			continue
+		}
+		selected = append(selected, host)
+	}
+
+	return selected, nil
+}
+
+// selectNamespaces returns the names of the namespaces selected by a ReplyURLSync, or nil if it selects every namespace
+func selectNamespaces(ctx context.Context, c client.Client, syncer v1beta1.ReplyURLSync) (sets.String, error) {
+	if syncer.Spec.Source.NamespaceSelector == nil {
+		return nil, nil
+	}
+
+	namespaceSelector, err := metav1.LabelSelectorAsSelector(syncer.Spec.Source.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceList := corev1.NamespaceList{}
+	if err = c.List(ctx, &namespaceList, client.MatchingLabelsSelector{Selector: namespaceSelector}); err != nil {
+		return nil, err
+	}
+
+	namespaces := sets.NewString()
+	for _, namespace := range namespaceList.Items {
+		namespaces.Insert(namespace.Name)
+	}
+	return namespaces, nil
+}
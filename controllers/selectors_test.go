@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSelectHosts(t *testing.T) {
+	newNamespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	c := fake.NewClientBuilder().WithObjects(
+		newNamespace("team-a", map[string]string{"team": "a"}),
+		newNamespace("team-b", map[string]string{"team": "b"}),
+		newNamespace("unlabelled", nil),
+	).Build()
+
+	hosts := []v1beta1.Host{
+		{Host: "test-app-1.sandbox.platform.hmcts.net", Namespace: "team-a", Labels: map[string]string{"auth": "sso"}},
+		{Host: "test-app-2.sandbox.platform.hmcts.net", Namespace: "team-b", Labels: map[string]string{"auth": "sso"}},
+		{Host: "test-app-3.sandbox.platform.hmcts.net", Namespace: "unlabelled"},
+		{Host: "test-app-4.sandbox.platform.hmcts.net", Namespace: "team-a", Labels: map[string]string{"auth": "none"}},
+	}
+
+	tests := []struct {
+		name              string
+		namespaceSelector *metav1.LabelSelector
+		labelSelector     *metav1.LabelSelector
+		expectedHosts     []string
+	}{
+		{
+			name: "selectors not set",
+			expectedHosts: []string{
+				"test-app-1.sandbox.platform.hmcts.net",
+				"test-app-2.sandbox.platform.hmcts.net",
+				"test-app-3.sandbox.platform.hmcts.net",
+				"test-app-4.sandbox.platform.hmcts.net",
+			},
+		},
+		{
+			name:              "namespace selector",
+			namespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			expectedHosts: []string{
+				"test-app-1.sandbox.platform.hmcts.net",
+				"test-app-4.sandbox.platform.hmcts.net",
+			},
+		},
+		{
+			name: "namespace selector expression",
+			namespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "team", Operator: metav1.LabelSelectorOpExists},
+			}},
+			expectedHosts: []string{
+				"test-app-1.sandbox.platform.hmcts.net",
+				"test-app-2.sandbox.platform.hmcts.net",
+				"test-app-4.sandbox.platform.hmcts.net",
+			},
+		},
+		{
+			name:              "namespace selector without matching namespaces",
+			namespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "c"}},
+		},
+		{
+			name:          "label selector",
+			labelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"auth": "sso"}},
+			expectedHosts: []string{
+				"test-app-1.sandbox.platform.hmcts.net",
+				"test-app-2.sandbox.platform.hmcts.net",
+			},
+		},
+		{
+			name:              "namespace and label selectors",
+			namespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			labelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"auth": "sso"}},
+			expectedHosts:     []string{"test-app-1.sandbox.platform.hmcts.net"},
+		},
+	}
+
+	for _, test := range tests {
+		syncer := v1beta1.ReplyURLSync{
+			Spec: v1beta1.ReplyURLSyncSpec{
+				Source: v1beta1.SourceSpec{
+					NamespaceSelector: test.namespaceSelector,
+					LabelSelector:     test.labelSelector,
+				},
+			},
+		}
+
+		selected, err := selectHosts(context.TODO(), c, syncer, hosts)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var selectedHosts []string
+		for _, host := range selected {
+			selectedHosts = append(selectedHosts, host.Host)
+		}
+
+		if !reflect.DeepEqual(selectedHosts, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", selectedHosts, test.expectedHosts, test.name)
+		}
+	}
+}
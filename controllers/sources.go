@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// hostSource is a kind of resource on the cluster that hosts are read from
+type hostSource interface {
+	// listHosts returns the hosts of every resource of the source that syncer reads hosts from
+	listHosts(ctx context.Context, c client.Client, syncer v1beta1.ReplyURLSync) ([]v1beta1.Host, error)
+}
+
+/*
+addHostSource adds a source hosts are read from, sources are added when they are watched,
+which can be while the manager is running for resources declared by a ReplyURLSync
+*/
+func (r *ReplyURLSyncReconciler) addHostSource(source hostSource) {
+	r.hostSourcesLock.Lock()
+	defer r.hostSourcesLock.Unlock()
+
+	r.hostSources = append(r.hostSources, source)
+}
+
+// listHostSources returns the sources added so far, sources added while they are being read aren't included
+func (r *ReplyURLSyncReconciler) listHostSources() []hostSource {
+	r.hostSourcesLock.RLock()
+	defer r.hostSourcesLock.RUnlock()
+
+	return append([]hostSource{}, r.hostSources...)
+}
+
+// listHosts returns the hosts of every source that are selected by the namespace and label selectors of syncer
+func listHosts(ctx context.Context, c client.Client, sources []hostSource, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	for _, source := range sources {
+		sourceHosts, err := source.listHosts(ctx, c, syncer)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, sourceHosts...)
+	}
+
+	return selectHosts(ctx, c, syncer, hosts)
+}
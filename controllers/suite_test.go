@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	appregistrationsazurev1alpha1 "github.com/hmcts/reply-urls-operator/api/v1alpha1"
+	appregistrationsazurev1beta1 "github.com/hmcts/reply-urls-operator/api/v1beta1"
 	"path/filepath"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -67,6 +68,9 @@ var _ = BeforeSuite(func() {
 	err = appregistrationsazurev1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = appregistrationsazurev1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
@@ -77,9 +81,10 @@ var _ = BeforeSuite(func() {
 
 	ctx := context.Background()
 	Expect(err).ToNot(HaveOccurred())
-	err = (&IngressReconciler{
-		Client: k8sManager.GetClient(),
-		Scheme: k8sManager.GetScheme(),
+	err = (&ReplyURLSyncReconciler{
+		Client:   k8sManager.GetClient(),
+		Scheme:   k8sManager.GetScheme(),
+		Recorder: k8sManager.GetEventRecorderFor("reply-urls-operator"),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=get;list;watch
+
+// VirtualServiceSource reads hosts from Istio VirtualServices
+var VirtualServiceSource ObjectSource = virtualServiceSource{}
+
+type virtualServiceSource struct{}
+
+func (virtualServiceSource) groupKind() schema.GroupKind {
+	return schema.GroupKind{Group: "networking.istio.io", Kind: "VirtualService"}
+}
+
+func (virtualServiceSource) usedBy(syncer v1beta1.ReplyURLSync) bool {
+	return syncer.Spec.Source.VirtualService != nil
+}
+
+// objectHosts returns the external hosts of a VirtualService bound to a Gateway selected by the sync
+func (virtualServiceSource) objectHosts(_ context.Context, _ client.Client, virtualService *unstructured.Unstructured, syncer v1beta1.ReplyURLSync) (hosts []v1beta1.Host, err error) {
+	virtualServiceSource := syncer.Spec.Source.VirtualService
+	if virtualServiceSource == nil {
+		return nil, nil
+	}
+
+	gateways, _, err := unstructured.NestedStringSlice(virtualService.Object, "spec", "gateways")
+	if err != nil {
+		return nil, err
+	}
+
+	if !boundToGateway(gateways, virtualService.GetNamespace(), virtualServiceSource.Gateways) {
+		return nil, nil
+	}
+
+	virtualServiceHosts, _, err := unstructured.NestedStringSlice(virtualService.Object, "spec", "hosts")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hostname := range virtualServiceHosts {
+		/*
+			Reply URLs can't be generated for wildcard hosts, and short names are
+			only resolved inside the mesh so they aren't external hosts
+		*/
+		if strings.HasPrefix(hostname, "*") || !strings.Contains(hostname, ".") {
+			continue
+		}
+		hosts = append(hosts, objectHost(virtualService, hostname))
+	}
+	return hosts, nil
+}
+
+/*
+boundToGateway reports whether one of the gateways of a VirtualService is a selected Gateway.
+Gateways without a namespace are in the namespace of the VirtualService and the reserved
+mesh gateway, used for traffic inside the mesh, is never selected.
+*/
+func boundToGateway(gateways []string, namespace string, selectedGateways []string) bool {
+	for _, gateway := range gateways {
+		if gateway == "mesh" {
+			continue
+		}
+
+		gatewayNamespace, gatewayName, isNamespaced := strings.Cut(gateway, "/")
+		if !isNamespaced {
+			gatewayNamespace, gatewayName = namespace, gateway
+		}
+
+		for _, selectedGateway := range selectedGateways {
+			selectedNamespace, selectedName, isNamespaced := strings.Cut(selectedGateway, "/")
+			if !isNamespaced {
+				selectedNamespace, selectedName = namespace, selectedGateway
+			}
+
+			if gatewayNamespace == selectedNamespace && gatewayName == selectedName {
+				return true
+			}
+		}
+	}
+	return false
+}
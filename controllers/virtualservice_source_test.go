@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hmcts/reply-urls-operator/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestVirtualServiceSourceObjectHosts(t *testing.T) {
+	tests := []struct {
+		name                 string
+		gateways             []interface{}
+		virtualServiceSource *v1beta1.VirtualServiceSource
+		expectedHosts        []string
+	}{
+		{
+			name:     "no virtual service source",
+			gateways: []interface{}{"istio-system/public-gateway"},
+		},
+		{
+			name:                 "namespaced gateway",
+			gateways:             []interface{}{"mesh", "istio-system/public-gateway"},
+			virtualServiceSource: &v1beta1.VirtualServiceSource{Gateways: []string{"istio-system/public-gateway"}},
+			expectedHosts:        []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:                 "gateway in the namespace of the virtual service",
+			gateways:             []interface{}{"public-gateway"},
+			virtualServiceSource: &v1beta1.VirtualServiceSource{Gateways: []string{"test-namespace/public-gateway"}},
+			expectedHosts:        []string{"test-app-1.sandbox.platform.hmcts.net", "test-app-2.sandbox.platform.hmcts.net"},
+		},
+		{
+			name:                 "gateway in another namespace",
+			gateways:             []interface{}{"public-gateway"},
+			virtualServiceSource: &v1beta1.VirtualServiceSource{Gateways: []string{"istio-system/public-gateway"}},
+		},
+		{
+			name:                 "mesh gateway",
+			gateways:             []interface{}{"mesh"},
+			virtualServiceSource: &v1beta1.VirtualServiceSource{Gateways: []string{"mesh"}},
+		},
+	}
+
+	for _, test := range tests {
+		virtualService := newTestObject("networking.istio.io/v1beta1", "VirtualService", "test-namespace", "test-app", map[string]interface{}{
+			"gateways": test.gateways,
+			"hosts": []interface{}{
+				"test-app-1.sandbox.platform.hmcts.net",
+				"test-app-2.sandbox.platform.hmcts.net",
+				"*.sandbox.platform.hmcts.net",
+				"test-app",
+			},
+		})
+
+		syncer := v1beta1.ReplyURLSync{}
+		syncer.Spec.Source.VirtualService = test.virtualServiceSource
+
+		hosts, err := VirtualServiceSource.objectHosts(context.TODO(), fake.NewClientBuilder().Build(), virtualService, syncer)
+		if err != nil {
+			t.Errorf("Unexpected error %v\nTest: %s\n", err, test.name)
+			continue
+		}
+
+		var hostnames []string
+		for _, host := range hosts {
+			hostnames = append(hostnames, host.Host)
+		}
+
+		if !reflect.DeepEqual(hostnames, test.expectedHosts) {
+			t.Errorf("Result %v not equal to the expected result %v\nTest: %s\n", hostnames, test.expectedHosts, test.name)
+		}
+	}
+}
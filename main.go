@@ -29,14 +29,17 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	appregistrationsazurev1alpha1 "github.com/hmcts/reply-urls-operator/api/v1alpha1"
+	appregistrationsazurev1beta1 "github.com/hmcts/reply-urls-operator/api/v1beta1"
 	"github.com/hmcts/reply-urls-operator/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -51,6 +54,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(appregistrationsazurev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(appregistrationsazurev1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -58,11 +62,14 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var coalescingWindow time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for ingressController manager. "+
 			"Enabling this will ensure there is only one active ingressController manager.")
+	flag.DurationVar(&coalescingWindow, "coalescing-window", 5*time.Second,
+		"How long to wait after a change to a resource hosts are read from before syncing, so bursts of changes are synced together.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -86,6 +93,8 @@ func main() {
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "5d010808.hmcts.net",
 		SyncPeriod:             &syncPeriod,
+		// Sources read their resources as unstructured objects, which are read live from the API server otherwise
+		NewClient: cluster.ClientBuilderWithOptions(cluster.ClientOptions{CacheUnstructured: true}),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -104,13 +113,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.IngressReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create ingressController", "ingressController", "Ingress")
+	replyURLSyncReconciler := &controllers.ReplyURLSyncReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		CoalescingWindow: coalescingWindow,
+		Recorder:         mgr.GetEventRecorderFor("reply-urls-operator"),
+	}
+	if err = replyURLSyncReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReplyURLSync")
 		os.Exit(1)
 	}
+
+	// Sources of hosts read from custom resources are only watched when their CRDs are installed
+	for _, source := range []controllers.ObjectSource{
+		controllers.HTTPRouteSource,
+		controllers.IngressRouteSource,
+		controllers.LegacyIngressRouteSource,
+		controllers.VirtualServiceSource,
+		controllers.DNSEndpointSource,
+		controllers.KnativeServiceSource,
+		controllers.DomainMappingSource,
+	} {
+		if err = replyURLSyncReconciler.WatchSource(source); meta.IsNoMatchError(err) {
+			setupLog.Info("CRD not installed, skipping source", "reason", err.Error())
+		} else if err != nil {
+			setupLog.Error(err, "unable to watch source", "controller", "ReplyURLSync")
+			os.Exit(1)
+		}
+	}
+
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&appregistrationsazurev1beta1.ReplyURLSync{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ReplyURLSync")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {